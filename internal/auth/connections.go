@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConnectionsLocal est la clé des fiber locals sous laquelle ConnectionRegistry.Middleware stocke le registre.
+// C'est une chaîne pour rester lisible depuis websocket.Conn.Locals après la poignée de main.
+const ConnectionsLocal = "game_connections"
+
+// ConnectionRegistry indexe les connexions WebSocket de jeu ouvertes par session et par utilisateur,
+// pour les fermer quand leur session est révoquée (logout, révocation d'un appareil, bannissement).
+// Le handler WebSocket enregistre chaque connexion avec Register et appelle la fonction retournée à sa fermeture.
+type ConnectionRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]map[*gameConnection]struct{}
+	users    map[int]int // Nombre de connexions ouvertes par utilisateur
+
+	onUserDisconnected func(userID int)
+}
+
+type gameConnection struct {
+	userID int
+	conn   io.Closer
+}
+
+// NewConnectionRegistry crée un registre vide.
+func NewConnectionRegistry() *ConnectionRegistry {
+	return &ConnectionRegistry{
+		sessions: make(map[string]map[*gameConnection]struct{}),
+		users:    make(map[int]int),
+	}
+}
+
+// OnUserDisconnected enregistre la fonction appelée quand la dernière connexion de jeu d'un utilisateur
+// est fermée ou retirée du registre. À appeler avant d'accepter des connexions.
+func (r *ConnectionRegistry) OnUserDisconnected(fn func(userID int)) {
+	r.onUserDisconnected = fn
+}
+
+// Register enregistre une connexion ouverte pour la session. La fonction retournée la retire du registre ;
+// elle peut être appelée plusieurs fois.
+func (r *ConnectionRegistry) Register(sessionID string, userID int, conn io.Closer) (release func()) {
+	entry := &gameConnection{userID: userID, conn: conn}
+
+	r.mu.Lock()
+	if r.sessions[sessionID] == nil {
+		r.sessions[sessionID] = make(map[*gameConnection]struct{})
+	}
+	r.sessions[sessionID][entry] = struct{}{}
+	r.users[userID]++
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		_, open := r.sessions[sessionID][entry]
+		last := open && r.remove(sessionID, entry)
+		r.mu.Unlock()
+
+		if last {
+			r.notifyDisconnected([]int{userID})
+		}
+	}
+}
+
+// IsSessionConnected indique si la session a au moins une connexion de jeu ouverte.
+func (r *ConnectionRegistry) IsSessionConnected(sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sessions[sessionID]) > 0
+}
+
+// DisconnectSession ferme les connexions de jeu de la session.
+func (r *ConnectionRegistry) DisconnectSession(sessionID string) {
+	r.mu.Lock()
+	var closing []io.Closer
+	var disconnected []int
+	for entry := range r.sessions[sessionID] {
+		closing = append(closing, entry.conn)
+		if r.remove(sessionID, entry) {
+			disconnected = append(disconnected, entry.userID)
+		}
+	}
+	r.mu.Unlock()
+
+	closeAll(closing)
+	r.notifyDisconnected(disconnected)
+}
+
+// DisconnectUser ferme toutes les connexions de jeu de l'utilisateur, toutes sessions confondues.
+func (r *ConnectionRegistry) DisconnectUser(userID int) {
+	r.mu.Lock()
+	var closing []io.Closer
+	var disconnected []int
+	for sessionID, entries := range r.sessions {
+		for entry := range entries {
+			if entry.userID == userID {
+				closing = append(closing, entry.conn)
+				if r.remove(sessionID, entry) {
+					disconnected = append(disconnected, userID)
+				}
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	closeAll(closing)
+	r.notifyDisconnected(disconnected)
+}
+
+// Middleware stocke le registre dans les fiber locals (clé ConnectionsLocal) pour le handler WebSocket.
+// À placer après l'authentification de la poignée de main.
+func (r *ConnectionRegistry) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(ConnectionsLocal, r)
+		return c.Next()
+	}
+}
+
+// remove retire une connexion du registre et retourne true si c'était la dernière de son utilisateur.
+// Appelé avec mu verrouillé.
+func (r *ConnectionRegistry) remove(sessionID string, entry *gameConnection) bool {
+	delete(r.sessions[sessionID], entry)
+	if len(r.sessions[sessionID]) == 0 {
+		delete(r.sessions, sessionID)
+	}
+
+	r.users[entry.userID]--
+	if r.users[entry.userID] > 0 {
+		return false
+	}
+	delete(r.users, entry.userID)
+	return true
+}
+
+// notifyDisconnected appelle OnUserDisconnected hors du verrou.
+func (r *ConnectionRegistry) notifyDisconnected(userIDs []int) {
+	if r.onUserDisconnected == nil {
+		return
+	}
+	for _, userID := range userIDs {
+		r.onUserDisconnected(userID)
+	}
+}
+
+// closeAll ferme les connexions hors du verrou : Close peut attendre l'envoi d'une trame de fermeture.
+func closeAll(conns []io.Closer) {
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import "testing"
+
+type fakeConn struct{ closed int }
+
+func (c *fakeConn) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestConnectionRegistryDisconnectSession(t *testing.T) {
+	r := NewConnectionRegistry()
+	first, second, other := &fakeConn{}, &fakeConn{}, &fakeConn{}
+	r.Register("s1", 1, first)
+	r.Register("s1", 1, second)
+	r.Register("s2", 1, other)
+
+	if !r.IsSessionConnected("s1") {
+		t.Fatal("s1 devrait être connectée")
+	}
+
+	r.DisconnectSession("s1")
+	if first.closed != 1 || second.closed != 1 {
+		t.Fatalf("connexions de s1 fermées %d et %d fois, attendu 1", first.closed, second.closed)
+	}
+	if other.closed != 0 {
+		t.Fatal("la connexion de s2 ne doit pas être fermée")
+	}
+	if r.IsSessionConnected("s1") || !r.IsSessionConnected("s2") {
+		t.Fatal("seule s2 devrait rester connectée")
+	}
+}
+
+func TestConnectionRegistryDisconnectUser(t *testing.T) {
+	r := NewConnectionRegistry()
+	mine, theirs := &fakeConn{}, &fakeConn{}
+	r.Register("s1", 1, mine)
+	r.Register("s2", 2, theirs)
+
+	r.DisconnectUser(1)
+	if mine.closed != 1 || theirs.closed != 0 {
+		t.Fatalf("fermetures: utilisateur 1 = %d, utilisateur 2 = %d", mine.closed, theirs.closed)
+	}
+	if r.IsSessionConnected("s1") || !r.IsSessionConnected("s2") {
+		t.Fatal("seule la session de l'utilisateur 2 devrait rester connectée")
+	}
+}
+
+func TestConnectionRegistryRelease(t *testing.T) {
+	r := NewConnectionRegistry()
+	conn := &fakeConn{}
+	release := r.Register("s1", 1, conn)
+
+	release()
+	release() // Sans effet
+	if r.IsSessionConnected("s1") {
+		t.Fatal("la session ne devrait plus être connectée après release")
+	}
+
+	r.DisconnectSession("s1")
+	if conn.closed != 0 {
+		t.Fatal("une connexion déjà fermée par le client ne doit pas être refermée")
+	}
+}
@@ -1,55 +1,275 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	// DefaultAccessTokenTTL est la durée de vie par défaut d'un access token.
+	DefaultAccessTokenTTL = time.Hour * 1
+	// DefaultRefreshTokenTTL est la durée de vie par défaut d'un refresh token.
+	DefaultRefreshTokenTTL = time.Hour * 24 * 7
+	// MFATokenTTL est la durée laissée au joueur pour saisir son code TOTP après le mot de passe.
+	MFATokenTTL = time.Minute * 5
+
+	// Issuer identifie le serveur émetteur des tokens (claim iss).
+	Issuer = "flumen_server"
+	// Audience identifie les destinataires autorisés des tokens (claim aud).
+	Audience = "flumen_client"
+)
+
+// TokenKind distingue les usages des tokens signés avec la même clé.
+type TokenKind string
+
+const (
+	TokenKindAccess  TokenKind = "access"      // Bearer token pour les routes /api
+	TokenKindRefresh TokenKind = "refresh"     // Uniquement pour /token/refresh
+	TokenKindMFA     TokenKind = "mfa_pending" // Mot de passe validé, second facteur attendu
+)
+
+// Méthodes d'authentification (claim amr, RFC 8176).
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+)
+
+var (
+	// ErrTokenRevoked est retourné quand le token ou sa session a été révoqué.
+	ErrTokenRevoked = errors.New("token révoqué")
+	// ErrWrongTokenKind est retourné quand le token n'est pas du type attendu (ex: refresh token utilisé comme bearer).
+	ErrWrongTokenKind = errors.New("type de token inattendu")
+)
+
+// RevocationStore permet de vérifier qu'un token n'a pas été révoqué (logout, bannissement).
+type RevocationStore interface {
+	IsTokenRevoked(ctx context.Context, tokenID, sessionID string) (bool, error)
+}
+
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username,omitempty"`
+	Roles     []string  `json:"roles,omitempty"`
+	AMR       []string  `json:"amr,omitempty"` // Méthodes d'authentification utilisées à la connexion
+	SessionID string    `json:"sid,omitempty"` // Famille de refresh tokens (une par connexion)
+	Kind      TokenKind `json:"typ"`
 	jwt.RegisteredClaims
 }
 
+// HasMFA indique si la connexion a été validée par un second facteur.
+func (c *Claims) HasMFA() bool {
+	for _, method := range c.AMR {
+		if method == AMROTP {
+			return true
+		}
+	}
+	return false
+}
+
+// Identity décrit l'utilisateur pour lequel des tokens sont émis.
+type Identity struct {
+	UserID   int
+	Username string
+	Roles    []string
+	MFA      bool // Connexion validée par un second facteur
+}
+
+// amr retourne les méthodes d'authentification de l'identité.
+func (id Identity) amr() []string {
+	if id.MFA {
+		return []string{AMRPassword, AMROTP}
+	}
+	return []string{AMRPassword}
+}
+
+// TokenPair regroupe les tokens émis lors d'une connexion ou d'un refresh.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	RefreshTokenID   string // jti du refresh token, à persister pour la rotation
+	SessionID        string
+	RefreshExpiresAt time.Time
+}
+
+// NewTokenID génère un identifiant aléatoire utilisable comme jti ou identifiant de session.
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// JWTConfig regroupe les paramètres du JWTService. Les valeurs nulles prennent les valeurs par défaut.
+type JWTConfig struct {
+	AccessTTL   time.Duration
+	RefreshTTL  time.Duration
+	Clock       func() time.Time // Horloge injectable pour les tests
+	Revocations RevocationStore  // Optionnel : rejette les tokens révoqués
+}
+
+// JWTService émet et valide les tokens. C'est l'unique implémentation partagée par
+// le login, le refresh et les handlers de l'API.
+type JWTService struct {
+	keys       *KeyRing
+	store      RevocationStore
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// NewJWTService crée un service de tokens signant avec le trousseau keys.
+func NewJWTService(keys *KeyRing, cfg JWTConfig) *JWTService {
+	s := &JWTService{
+		keys:       keys,
+		store:      cfg.Revocations,
+		accessTTL:  cfg.AccessTTL,
+		refreshTTL: cfg.RefreshTTL,
+		now:        cfg.Clock,
+	}
+	if s.accessTTL <= 0 {
+		s.accessTTL = DefaultAccessTokenTTL
+	}
+	if s.refreshTTL <= 0 {
+		s.refreshTTL = DefaultRefreshTokenTTL
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	return s
+}
+
+// Keys retourne le trousseau de clés du service (rotation, JWKS).
+func (s *JWTService) Keys() *KeyRing {
+	return s.keys
+}
+
 // GenerateTokens crée un nouvel access token et un refresh token.
-func GenerateTokens(userID, username, secret string) (accessToken string, refreshToken string, err error) {
+// Le refresh token porte un jti unique et l'identifiant de session, ce qui
+// permet de le consommer une seule fois lors de la rotation.
+func (s *JWTService) GenerateTokens(id Identity, sessionID string) (*TokenPair, error) {
+	now := s.now()
+	subject := strconv.Itoa(id.UserID)
+
 	// Créer l'access token
+	accessID, err := NewTokenID()
+	if err != nil {
+		return nil, err
+	}
 	accessClaims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    id.UserID,
+		Username:  id.Username,
+		Roles:     id.Roles,
+		AMR:       id.amr(),
+		SessionID: sessionID,
+		Kind:      TokenKindAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 1)), // 1 heure
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        accessID,
+			Issuer:    Issuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{Audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	accessToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString([]byte(secret))
+	accessToken, err := s.keys.Sign(accessClaims)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	// Créer le refresh token
+	refreshID, err := NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := now.Add(s.refreshTTL)
 	refreshClaims := &Claims{
-		UserID: userID,
+		UserID:    id.UserID,
+		AMR:       id.amr(),
+		SessionID: sessionID,
+		Kind:      TokenKindRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24 * 7)), // 7 jours
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        refreshID,
+			Issuer:    Issuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{Audience},
+			ExpiresAt: jwt.NewNumericDate(refreshExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	refreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(secret))
+	refreshToken, err := s.keys.Sign(refreshClaims)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	return accessToken, refreshToken, nil
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		RefreshTokenID:   refreshID,
+		SessionID:        sessionID,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
 }
 
-// ValidateToken valide un token et retourne les claims.
-func ValidateToken(tokenString, secret string) (*Claims, error) {
+// GenerateMFAToken crée le token temporaire remis après le mot de passe quand le 2FA est activé.
+// Il ne donne accès qu'à /auth/mfa/verify.
+func (s *JWTService) GenerateMFAToken(userID int) (string, error) {
+	now := s.now()
+	tokenID, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &Claims{
+		UserID: userID,
+		AMR:    []string{AMRPassword},
+		Kind:   TokenKindMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    Issuer,
+			Subject:   strconv.Itoa(userID),
+			Audience:  jwt.ClaimStrings{Audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(MFATokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return s.keys.Sign(claims)
+}
+
+// ValidateToken valide un access token et retourne les claims.
+func (s *JWTService) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return s.validate(ctx, tokenString, TokenKindAccess)
+}
+
+// ValidateRefreshToken valide un refresh token et retourne les claims.
+func (s *JWTService) ValidateRefreshToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return s.validate(ctx, tokenString, TokenKindRefresh)
+}
+
+// ValidateMFAToken valide un token "mfa_pending" et retourne les claims.
+func (s *JWTService) ValidateMFAToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return s.validate(ctx, tokenString, TokenKindMFA)
+}
+
+// validate valide un token du type attendu.
+// La clé est choisie d'après l'en-tête kid et doit utiliser le même algorithme que le token ;
+// l'émetteur et l'audience doivent correspondre. Si un RevocationStore est configuré,
+// le token est rejeté lorsque son jti ou sa session a été révoqué.
+func (s *JWTService) validate(ctx context.Context, tokenString string, expected TokenKind) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keys.keyFunc,
+		jwt.WithValidMethods(s.keys.algorithms()),
+		jwt.WithIssuer(Issuer),
+		jwt.WithAudience(Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
 
 	if err != nil {
 		return nil, err
@@ -59,5 +279,24 @@ func ValidateToken(tokenString, secret string) (*Claims, error) {
 		return nil, jwt.ErrSignatureInvalid
 	}
 
+	if claims.Kind != expected {
+		return nil, ErrWrongTokenKind
+	}
+
+	// Les tokens "mfa_pending" précèdent la création de la session et expirent vite
+	if s.store != nil && expected != TokenKindMFA {
+		// Un token sans jti ni session ne peut pas être révoqué : on le refuse
+		if claims.ID == "" || claims.SessionID == "" {
+			return nil, ErrTokenRevoked
+		}
+		revoked, err := s.store.IsTokenRevoked(ctx, claims.ID, claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+
 	return claims, nil
 }
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestJWTService(t *testing.T, now *time.Time) *JWTService {
+	t.Helper()
+	keys, err := KeyRingFromSecret("test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewJWTService(keys, JWTConfig{
+		AccessTTL: 15 * time.Minute,
+		Clock:     func() time.Time { return *now },
+	})
+}
+
+func TestAccessTokenExpiresWithClock(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestJWTService(t, &now)
+	ctx := context.Background()
+
+	tokens, err := s.GenerateTokens(Identity{UserID: 1, Username: "alice"}, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(14 * time.Minute)
+	claims, err := s.ValidateToken(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("token encore valide refusé: %v", err)
+	}
+	if claims.UserID != 1 || claims.SessionID != "session" {
+		t.Fatalf("claims = %+v", claims)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := s.ValidateToken(ctx, tokens.AccessToken); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("token expiré: erreur = %v, attendu ErrTokenExpired", err)
+	}
+
+	// Le refresh token vit plus longtemps
+	if _, err := s.ValidateRefreshToken(ctx, tokens.RefreshToken); err != nil {
+		t.Fatalf("refresh token refusé: %v", err)
+	}
+}
+
+func TestValidateRejectsWrongTokenKind(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestJWTService(t, &now)
+
+	mfaToken, err := s.GenerateMFAToken(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(context.Background(), mfaToken); !errors.Is(err, ErrWrongTokenKind) {
+		t.Fatalf("token mfa_pending accepté comme access token: %v", err)
+	}
+}
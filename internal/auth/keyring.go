@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// LegacyKeyID est le kid implicite des tokens signés avant l'introduction du trousseau (sans en-tête kid).
+const LegacyKeyID = "default"
+
+var (
+	// ErrUnknownKey est retourné quand le kid du token ne correspond à aucune clé du trousseau.
+	ErrUnknownKey = errors.New("clé de signature inconnue")
+	// ErrVerifyOnlyKey est retourné quand on tente de signer avec une clé publique seule.
+	ErrVerifyOnlyKey = errors.New("clé de vérification uniquement")
+	// ErrMissingKeyID est retourné pour un token sans kid quand le trousseau n'accepte pas les tokens historiques.
+	ErrMissingKeyID = errors.New("token sans kid")
+)
+
+// SigningKey est une clé identifiée par son kid.
+// Les clés asymétriques (EdDSA, RS256) peuvent être publiées pour que d'autres services vérifient les tokens.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey crée une clé symétrique HS256.
+func NewHMACKey(id string, secret []byte) (*SigningKey, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret HS256 %q vide", id)
+	}
+	return &SigningKey{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+// NewEd25519Key crée une clé asymétrique EdDSA.
+func NewEd25519Key(id string, private ed25519.PrivateKey) *SigningKey {
+	return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, signKey: private, verifyKey: private.Public()}
+}
+
+// NewRSAKey crée une clé asymétrique RS256.
+func NewRSAKey(id string, private *rsa.PrivateKey) *SigningKey {
+	return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}
+}
+
+// NewVerificationKey crée une clé publique seule (EdDSA ou RS256), acceptée en vérification mais jamais utilisée pour signer.
+func NewVerificationKey(id string, public crypto.PublicKey) (*SigningKey, error) {
+	switch pub := public.(type) {
+	case ed25519.PublicKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, verifyKey: pub}, nil
+	case *rsa.PublicKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, verifyKey: pub}, nil
+	default:
+		return nil, fmt.Errorf("type de clé publique non supporté pour %q", id)
+	}
+}
+
+// KeyRing contient la clé de signature courante et les clés encore acceptées en vérification.
+type KeyRing struct {
+	mu      sync.RWMutex
+	current string
+	legacy  string // kid des tokens émis sans en-tête kid, vide s'ils sont refusés
+	keys    map[string]*SigningKey
+}
+
+// NewKeyRing crée un trousseau signant avec current et acceptant aussi les clés previous.
+func NewKeyRing(current *SigningKey, previous ...*SigningKey) (*KeyRing, error) {
+	if current == nil || current.signKey == nil {
+		return nil, ErrVerifyOnlyKey
+	}
+
+	r := &KeyRing{current: current.ID, keys: make(map[string]*SigningKey)}
+	for _, key := range append([]*SigningKey{current}, previous...) {
+		if key.ID == "" {
+			return nil, errors.New("kid vide")
+		}
+		if _, exists := r.keys[key.ID]; exists {
+			return nil, fmt.Errorf("kid dupliqué: %s", key.ID)
+		}
+		r.keys[key.ID] = key
+	}
+	return r, nil
+}
+
+// KeyRingFromSecret crée un trousseau à clé HS256 unique, compatible avec les tokens émis sans kid.
+func KeyRingFromSecret(secret string) (*KeyRing, error) {
+	key, err := NewHMACKey(LegacyKeyID, []byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewKeyRing(key)
+	if err != nil {
+		return nil, err
+	}
+	r.legacy = LegacyKeyID
+	return r, nil
+}
+
+// AcceptLegacyTokens fait vérifier les tokens sans kid avec la clé id (l'ancien secret HS256 lors du
+// passage au fichier de trousseau). Sans cet appel, un trousseau créé par NewKeyRing les refuse.
+func (r *KeyRing) AcceptLegacyTokens(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[id]; !ok {
+		return fmt.Errorf("clé historique %q: %w", id, ErrUnknownKey)
+	}
+	r.legacy = id
+	return nil
+}
+
+// Rotate fait de next la clé de signature courante. L'ancienne clé reste acceptée jusqu'à Retire.
+func (r *KeyRing) Rotate(next *SigningKey) error {
+	if next == nil || next.signKey == nil {
+		return ErrVerifyOnlyKey
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.keys[next.ID]; exists {
+		return fmt.Errorf("kid dupliqué: %s", next.ID)
+	}
+	r.keys[next.ID] = next
+	r.current = next.ID
+	return nil
+}
+
+// Retire retire une ancienne clé : les tokens signés avec elle seront refusés.
+func (r *KeyRing) Retire(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == r.current {
+		return errors.New("impossible de retirer la clé courante")
+	}
+	if _, ok := r.keys[id]; !ok {
+		return ErrUnknownKey
+	}
+	delete(r.keys, id)
+	if id == r.legacy {
+		r.legacy = ""
+	}
+	return nil
+}
+
+// Sign signe les claims avec la clé courante et renseigne l'en-tête kid.
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	r.mu.RLock()
+	key := r.keys[r.current]
+	r.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// keyFunc retrouve la clé de vérification à partir du kid et refuse un algorithme différent de celui de la clé.
+func (r *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	r.mu.RLock()
+	if kid == "" {
+		kid = r.legacy
+	}
+	key, ok := r.keys[kid]
+	r.mu.RUnlock()
+	if kid == "" {
+		return nil, ErrMissingKeyID
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.verifyKey, nil
+}
+
+// algorithms retourne les algorithmes des clés du trousseau.
+func (r *KeyRing) algorithms() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range r.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// JWK représente une clé publique au format JSON Web Key.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS retourne les clés publiques du trousseau (les clés HS256 ne sont jamais publiées).
+func (r *KeyRing) JWKS() []JWK {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := []JWK{}
+	for _, key := range r.keys {
+		switch pub := key.verifyKey.(type) {
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Algorithm: key.Method.Alg(),
+				Use:       "sig",
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(pub),
+			})
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Algorithm: key.Method.Alg(),
+				Use:       "sig",
+				N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		}
+	}
+	return keys
+}
+
+// keyFileEntry décrit une clé dans le fichier de configuration du trousseau.
+type keyFileEntry struct {
+	ID             string `json:"kid"`
+	Algorithm      string `json:"alg"`
+	Secret         string `json:"secret,omitempty"`           // HS256
+	PrivateKeyFile string `json:"private_key_file,omitempty"` // EdDSA / RS256 (PEM)
+	PublicKeyFile  string `json:"public_key_file,omitempty"`  // EdDSA / RS256 en vérification seule (PEM)
+}
+
+// LoadKeyRing charge un trousseau depuis un fichier JSON de la forme :
+//
+//	{"current": "2026-10", "legacy": "default", "keys": [{"kid": "2026-10", "alg": "EdDSA", "private_key_file": "jwt.pem"}, ...]}
+//
+// legacy, facultatif, désigne la clé qui vérifie les tokens émis sans kid ; sans lui ils sont refusés.
+func LoadKeyRing(path string) (*KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("lecture du trousseau JWT: %w", err)
+	}
+
+	var file struct {
+		Current string         `json:"current"`
+		Legacy  string         `json:"legacy"`
+		Keys    []keyFileEntry `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("trousseau JWT invalide: %w", err)
+	}
+
+	var current *SigningKey
+	var previous []*SigningKey
+	for _, entry := range file.Keys {
+		key, err := entry.load()
+		if err != nil {
+			return nil, err
+		}
+		if key.ID == file.Current {
+			current = key
+		} else {
+			previous = append(previous, key)
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("clé courante %q absente du trousseau", file.Current)
+	}
+
+	r, err := NewKeyRing(current, previous...)
+	if err != nil {
+		return nil, err
+	}
+	if file.Legacy != "" {
+		if err := r.AcceptLegacyTokens(file.Legacy); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (e keyFileEntry) load() (*SigningKey, error) {
+	if e.Algorithm == jwt.SigningMethodHS256.Alg() {
+		return NewHMACKey(e.ID, []byte(e.Secret))
+	}
+
+	if e.PrivateKeyFile != "" {
+		pem, err := os.ReadFile(e.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("lecture de la clé %q: %w", e.ID, err)
+		}
+		switch e.Algorithm {
+		case jwt.SigningMethodEdDSA.Alg():
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, fmt.Errorf("clé %q: %w", e.ID, err)
+			}
+			return NewEd25519Key(e.ID, private.(ed25519.PrivateKey)), nil
+		case jwt.SigningMethodRS256.Alg():
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, fmt.Errorf("clé %q: %w", e.ID, err)
+			}
+			return NewRSAKey(e.ID, private), nil
+		}
+	}
+
+	if e.PublicKeyFile != "" {
+		pem, err := os.ReadFile(e.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("lecture de la clé %q: %w", e.ID, err)
+		}
+		var public crypto.PublicKey
+		switch e.Algorithm {
+		case jwt.SigningMethodEdDSA.Alg():
+			public, err = jwt.ParseEdPublicKeyFromPEM(pem)
+		case jwt.SigningMethodRS256.Alg():
+			public, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		default:
+			err = fmt.Errorf("algorithme non supporté: %s", e.Algorithm)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("clé %q: %w", e.ID, err)
+		}
+		return NewVerificationKey(e.ID, public)
+	}
+
+	return nil, fmt.Errorf("clé %q: algorithme %q non supporté ou fichier manquant", e.ID, e.Algorithm)
+}
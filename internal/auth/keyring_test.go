@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// legacyToken signe un token sans en-tête kid, comme avant l'introduction du trousseau.
+func legacyToken(t *testing.T, secret string) *jwt.Token {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func writeKeyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeyRingRotateRejectsDuplicateKid(t *testing.T) {
+	ring, err := KeyRingFromSecret("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := NewHMACKey(LegacyKeyID, []byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Rotate(replacement); err == nil {
+		t.Fatal("une rotation vers un kid existant doit être refusée")
+	}
+
+	// La clé d'origine vérifie toujours ses tokens
+	if _, err := ring.keyFunc(legacyToken(t, "secret")); err != nil {
+		t.Fatalf("clé d'origine remplacée: %v", err)
+	}
+}
+
+func TestLoadedKeyRingLegacyTokens(t *testing.T) {
+	keys := `"keys": [{"kid": "2026-10", "alg": "HS256", "secret": "new"}, {"kid": "default", "alg": "HS256", "secret": "old"}]`
+
+	ring, err := LoadKeyRing(writeKeyFile(t, `{"current": "2026-10", `+keys+`}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ring.keyFunc(legacyToken(t, "old")); !errors.Is(err, ErrMissingKeyID) {
+		t.Fatalf("token sans kid: erreur = %v, attendu ErrMissingKeyID", err)
+	}
+
+	ring, err = LoadKeyRing(writeKeyFile(t, `{"current": "2026-10", "legacy": "default", `+keys+`}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ring.keyFunc(legacyToken(t, "old")); err != nil {
+		t.Fatalf("token sans kid refusé malgré legacy: %v", err)
+	}
+
+	if _, err := LoadKeyRing(writeKeyFile(t, `{"current": "2026-10", "legacy": "missing", `+keys+`}`)); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("legacy inconnu: erreur = %v, attendu ErrUnknownKey", err)
+	}
+}
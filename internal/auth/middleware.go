@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// principalKey est la clé des fiber locals sous laquelle le Principal est stocké.
+type principalKey struct{}
+
+// Principal représente l'utilisateur authentifié d'une requête.
+type Principal struct {
+	UserID    int
+	Username  string
+	Roles     []string
+	SessionID string
+	TokenID   string
+	MFA       bool // Connexion validée par un second facteur
+	ExpiresAt time.Time
+}
+
+// Middleware valide le bearer token une seule fois et stocke le Principal dans les fiber locals.
+// Les handlers le récupèrent avec PrincipalFrom.
+func (s *JWTService) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || tokenString == "" {
+			return Unauthorized(c, "", "Token manquant")
+		}
+
+		claims, err := s.ValidateToken(c.Context(), tokenString)
+		if err != nil {
+			return Unauthorized(c, "invalid_token", "Token invalide")
+		}
+
+		principal := &Principal{
+			UserID:    claims.UserID,
+			Username:  claims.Username,
+			Roles:     claims.Roles,
+			SessionID: claims.SessionID,
+			TokenID:   claims.ID,
+			MFA:       claims.HasMFA(),
+		}
+		if claims.ExpiresAt != nil {
+			principal.ExpiresAt = claims.ExpiresAt.Time
+		}
+		c.Locals(principalKey{}, principal)
+
+		return c.Next()
+	}
+}
+
+// PrincipalFrom retourne le Principal stocké par Middleware.
+func PrincipalFrom(c *fiber.Ctx) (*Principal, bool) {
+	principal, ok := c.Locals(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// RequireMFA refuse les requêtes dont la connexion n'a pas été validée par un second facteur.
+// À placer après Middleware, par exemple sur les routes d'administration.
+func RequireMFA() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			return Unauthorized(c, "", "Token manquant")
+		}
+		if !principal.MFA {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "Authentification à deux facteurs requise",
+				"code":    "mfa_required",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// Unauthorized renvoie une réponse 401 uniforme avec l'en-tête WWW-Authenticate (RFC 6750).
+func Unauthorized(c *fiber.Ctx, code, message string) error {
+	challenge := `Bearer realm="flumen"`
+	if code != "" {
+		challenge += fmt.Sprintf(`, error=%q`, code)
+	}
+	c.Set(fiber.HeaderWWWAuthenticate, challenge)
+
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"success": false,
+		"error":   message,
+	})
+}
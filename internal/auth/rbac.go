@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Permission autorise une action d'administration ou de modération.
+type Permission string
+
+const (
+	PermBanUser     Permission = "users.ban"
+	PermMuteUser    Permission = "users.mute"
+	PermViewUsers   Permission = "users.read"
+	PermViewAudit   Permission = "audit.read"
+	PermViewMetrics Permission = "metrics.read"
+	PermManageRoles Permission = "roles.manage"
+	PermTeleport    Permission = "characters.teleport"
+	PermGrantItems  Permission = "items.grant"
+	PermGrantXP     Permission = "characters.experience"
+)
+
+// Rôles attribuables. Tout utilisateur est implicitement joueur.
+const (
+	RolePlayer    = "player"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// rolePermissions associe chaque rôle à ses permissions.
+var rolePermissions = map[string][]Permission{
+	RolePlayer: {},
+	RoleModerator: {
+		PermViewUsers,
+		PermViewAudit,
+		PermMuteUser,
+		PermBanUser,
+		PermTeleport,
+	},
+	RoleAdmin: {
+		PermViewUsers,
+		PermViewAudit,
+		PermMuteUser,
+		PermBanUser,
+		PermTeleport,
+		PermGrantItems,
+		PermGrantXP,
+		PermManageRoles,
+		PermViewMetrics,
+	},
+}
+
+// IsValidRole indique si role est un rôle connu.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsStaffRole indique si le rôle donne des permissions d'administration ou de modération.
+func IsStaffRole(role string) bool {
+	return len(rolePermissions[role]) > 0
+}
+
+// HasPermission indique si l'un des rôles accorde la permission.
+func HasPermission(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Can indique si l'utilisateur authentifié dispose de la permission.
+func (p *Principal) Can(perm Permission) bool {
+	return HasPermission(p.Roles, perm)
+}
+
+// RequirePermission refuse les requêtes dont l'utilisateur n'a pas la permission.
+// À placer après Middleware ; les rôles proviennent du token et sont rechargés à chaque refresh.
+func RequirePermission(perm Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			return Unauthorized(c, "", "Token manquant")
+		}
+		if !principal.Can(perm) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "Permission refusée",
+				"code":    "forbidden",
+			})
+		}
+		return c.Next()
+	}
+}
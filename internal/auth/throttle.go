@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// maxThrottleEntries déclenche le nettoyage des entrées expirées au-delà de cette taille.
+const maxThrottleEntries = 10000
+
+// ThrottleConfig paramètre le backoff exponentiel et le verrouillage après échecs.
+type ThrottleConfig struct {
+	FreeAttempts     int           // Échecs tolérés avant le premier délai
+	BaseDelay        time.Duration // Délai après le premier échec au-delà de FreeAttempts, doublé à chaque échec
+	MaxDelay         time.Duration // Plafond du backoff
+	LockoutThreshold int           // Échecs entraînant un verrouillage temporaire
+	LockoutDuration  time.Duration
+	ResetAfter       time.Duration // Les échecs plus anciens sont oubliés
+}
+
+var (
+	// DefaultAccountThrottle s'applique par identifiant (email ou username), qu'il existe ou non.
+	DefaultAccountThrottle = ThrottleConfig{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute * 5,
+		LockoutThreshold: 10,
+		LockoutDuration:  time.Minute * 15,
+		ResetAfter:       time.Hour,
+	}
+	// DefaultIPThrottle s'applique par adresse IP, plus permissif pour les IP partagées.
+	DefaultIPThrottle = ThrottleConfig{
+		FreeAttempts:     20,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute * 15,
+		LockoutThreshold: 100,
+		LockoutDuration:  time.Hour,
+		ResetAfter:       time.Hour,
+	}
+)
+
+type attemptState struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// throttle compte les échecs par clé et calcule le délai avant la prochaine tentative.
+type throttle struct {
+	mu      sync.Mutex
+	cfg     ThrottleConfig
+	entries map[string]*attemptState
+	now     func() time.Time
+}
+
+func newThrottle(cfg ThrottleConfig, now func() time.Time) *throttle {
+	return &throttle{cfg: cfg, entries: make(map[string]*attemptState), now: now}
+}
+
+// wait retourne le temps restant avant qu'une tentative soit autorisée pour key.
+func (t *throttle) wait(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.entries[key]
+	if !ok {
+		return 0
+	}
+	if remaining := state.blockedUntil.Sub(t.now()); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// failure enregistre un échec et retourne true si la clé est désormais verrouillée.
+func (t *throttle) failure(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if len(t.entries) > maxThrottleEntries {
+		t.prune(now)
+	}
+
+	state, ok := t.entries[key]
+	if !ok || now.Sub(state.lastFailure) > t.cfg.ResetAfter {
+		state = &attemptState{}
+		t.entries[key] = state
+	}
+	// Un verrouillage expiré repart du seuil de backoff : sans cela chaque nouvel échec
+	// reverrouillerait immédiatement la clé pour LockoutDuration.
+	if state.failures >= t.cfg.LockoutThreshold && !now.Before(state.blockedUntil) {
+		state.failures = t.cfg.FreeAttempts
+	}
+	state.failures++
+	state.lastFailure = now
+
+	if state.failures >= t.cfg.LockoutThreshold {
+		state.blockedUntil = now.Add(t.cfg.LockoutDuration)
+		return true
+	}
+	if excess := state.failures - t.cfg.FreeAttempts; excess > 0 {
+		delay := t.cfg.BaseDelay
+		for i := 1; i < excess && delay < t.cfg.MaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > t.cfg.MaxDelay {
+			delay = t.cfg.MaxDelay
+		}
+		state.blockedUntil = now.Add(delay)
+	}
+	return false
+}
+
+// reset oublie les échecs de key.
+func (t *throttle) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+// prune supprime les entrées qui ne bloquent plus et dont les échecs sont oubliés.
+func (t *throttle) prune(now time.Time) {
+	for key, state := range t.entries {
+		if now.After(state.blockedUntil) && now.Sub(state.lastFailure) > t.cfg.ResetAfter {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// LoginGuard limite les tentatives de connexion par IP et par identifiant.
+// L'état est en mémoire : chaque instance du serveur applique ses propres limites.
+type LoginGuard struct {
+	ip      *throttle
+	account *throttle
+}
+
+// NewLoginGuard crée un LoginGuard avec les configurations par IP et par compte.
+func NewLoginGuard(ipCfg, accountCfg ThrottleConfig) *LoginGuard {
+	return &LoginGuard{
+		ip:      newThrottle(ipCfg, time.Now),
+		account: newThrottle(accountCfg, time.Now),
+	}
+}
+
+// Check retourne le délai à attendre avant d'autoriser une tentative (0 si autorisée).
+func (g *LoginGuard) Check(ip, identifier string) time.Duration {
+	ipWait := g.ip.wait(ip)
+	accountWait := g.account.wait(identifier)
+	if ipWait > accountWait {
+		return ipWait
+	}
+	return accountWait
+}
+
+// RecordFailure enregistre un échec et retourne true si l'identifiant vient d'être verrouillé.
+func (g *LoginGuard) RecordFailure(ip, identifier string) bool {
+	g.ip.failure(ip)
+	return g.account.failure(identifier)
+}
+
+// RecordSuccess réinitialise le compteur de l'identifiant.
+// Le compteur de l'IP n'est pas remis à zéro : se connecter à son propre compte
+// ne doit pas permettre de continuer à attaquer les autres.
+func (g *LoginGuard) RecordSuccess(identifier string) {
+	g.account.reset(identifier)
+}
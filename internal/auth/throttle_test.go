@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleExpiredLockoutRestartsBackoff(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := ThrottleConfig{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 5,
+		LockoutDuration:  time.Minute * 15,
+		ResetAfter:       time.Hour * 24,
+	}
+	th := newThrottle(cfg, func() time.Time { return now })
+
+	locked := false
+	for i := 0; i < cfg.LockoutThreshold; i++ {
+		locked = th.failure("alice")
+	}
+	if !locked {
+		t.Fatal("la clé devrait être verrouillée au seuil")
+	}
+	if wait := th.wait("alice"); wait != cfg.LockoutDuration {
+		t.Fatalf("attente = %v, attendu %v", wait, cfg.LockoutDuration)
+	}
+
+	// Après le verrouillage, un nouvel échec ne reverrouille pas : il reprend le backoff
+	now = now.Add(cfg.LockoutDuration)
+	if th.failure("alice") {
+		t.Fatal("un échec après expiration du verrouillage ne doit pas reverrouiller")
+	}
+	if wait := th.wait("alice"); wait != cfg.BaseDelay {
+		t.Fatalf("attente = %v, attendu %v", wait, cfg.BaseDelay)
+	}
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WSTicketTTL est la durée de validité d'un ticket de connexion WebSocket.
+const WSTicketTTL = 30 * time.Second
+
+// maxTicketEntries déclenche le nettoyage des tickets expirés au-delà de cette taille.
+const maxTicketEntries = 10000
+
+// ErrInvalidTicket est retourné pour un ticket inconnu, expiré, déjà utilisé ou présenté depuis une autre IP.
+var ErrInvalidTicket = errors.New("ticket invalide ou expiré")
+
+type wsTicket struct {
+	principal Principal
+	ip        string
+	expiresAt time.Time
+}
+
+// TicketStore délivre les tickets à usage unique échangés contre un access token
+// pour ouvrir le WebSocket de jeu, afin que l'access token n'apparaisse jamais dans une URL.
+// L'état est en mémoire : le ticket doit être utilisé sur l'instance qui l'a délivré.
+type TicketStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]*wsTicket // Indexés par empreinte SHA-256
+	now     func() time.Time
+}
+
+// NewTicketStore crée un TicketStore dont les tickets expirent après ttl.
+func NewTicketStore(ttl time.Duration) *TicketStore {
+	if ttl <= 0 {
+		ttl = WSTicketTTL
+	}
+	return &TicketStore{ttl: ttl, tickets: make(map[string]*wsTicket), now: time.Now}
+}
+
+// Issue délivre un ticket pour principal, utilisable une seule fois depuis l'adresse ip.
+func (s *TicketStore) Issue(principal *Principal, ip string) (string, time.Time, error) {
+	ticket, hash, err := NewOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if len(s.tickets) > maxTicketEntries {
+		s.prune(now)
+	}
+
+	expiresAt := now.Add(s.ttl)
+	s.tickets[hash] = &wsTicket{principal: *principal, ip: ip, expiresAt: expiresAt}
+	return ticket, expiresAt, nil
+}
+
+// Redeem consomme le ticket et retourne le Principal pour lequel il a été délivré.
+// Le ticket est supprimé même si l'IP ne correspond pas, pour empêcher toute nouvelle tentative.
+func (s *TicketStore) Redeem(ticket, ip string) (*Principal, error) {
+	hash := HashOpaqueToken(ticket)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.tickets[hash]
+	if !ok {
+		return nil, ErrInvalidTicket
+	}
+	delete(s.tickets, hash)
+
+	if entry.ip != ip || !s.now().Before(entry.expiresAt) {
+		return nil, ErrInvalidTicket
+	}
+	principal := entry.principal
+	return &principal, nil
+}
+
+// prune supprime les tickets expirés.
+func (s *TicketStore) prune(now time.Time) {
+	for hash, entry := range s.tickets {
+		if !now.Before(entry.expiresAt) {
+			delete(s.tickets, hash)
+		}
+	}
+}
+
+// Middleware valide le ticket du paramètre ?ticket= lors de la poignée de main WebSocket
+// et stocke le Principal dans les fiber locals, comme JWTService.Middleware.
+func (s *TicketStore) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			return Unauthorized(c, "", "Ticket manquant")
+		}
+
+		principal, err := s.Redeem(ticket, c.IP())
+		if err != nil {
+			return Unauthorized(c, "invalid_token", "Ticket invalide")
+		}
+		c.Locals(principalKey{}, principal)
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Actions enregistrées dans le journal d'audit.
+const (
+	AuditUserRegister     = "user.register"
+	AuditUserLogin        = "user.login"
+	AuditUserLogout       = "user.logout"
+	AuditUserLogoutAll    = "user.logout_all"
+	AuditEmailVerified    = "user.email_verified"
+	AuditPasswordReset    = "user.password_reset"
+	AuditMFAEnabled       = "user.mfa_enabled"
+	AuditMFADisabled      = "user.mfa_disabled"
+	AuditSessionRevoked   = "user.session_revoked"
+	AuditRoleGranted      = "user.role_granted"
+	AuditRoleRevoked      = "user.role_revoked"
+	AuditSanctionIssued   = "user.sanction_issued"
+	AuditSanctionLifted   = "user.sanction_lifted"
+	AuditCharacterCreate  = "character.create"
+	AuditCharacterSelect  = "character.select"
+	AuditCharacterDelete  = "character.delete"
+	AuditCharacterRestore = "character.restore"
+	AuditCharacterMove    = "character.move"
+	AuditCharacterXP      = "character.experience"
+	AuditSpellLevelUp     = "character.spell_level_up"
+	AuditCharacterPurge   = "character.purge"
+)
+
+// Nombre d'entrées retournées par QueryAuditLog.
+const (
+	defaultAuditQueryLimit = 100
+	maxAuditQueryLimit     = 1000
+)
+
+// AuditEntry est une entrée du journal d'audit. Les entrées ne sont jamais modifiées ni supprimées.
+type AuditEntry struct {
+	ID          int64           `db:"id"`
+	ActorID     *int            `db:"actor_id"` // nil : action du système
+	Action      string          `db:"action"`
+	UserID      *int            `db:"user_id"`      // Compte concerné
+	CharacterID *int            `db:"character_id"` // Personnage concerné
+	Before      json.RawMessage `db:"before"`
+	After       json.RawMessage `db:"after"`
+	IPAddress   string          `db:"ip_address"`
+	CreatedAt   time.Time       `db:"created_at"`
+}
+
+// AuditFilter restreint une recherche dans le journal d'audit. Les champs vides ne filtrent pas.
+type AuditFilter struct {
+	UserID      *int
+	CharacterID *int
+	Action      string // Action exacte, ou préfixe terminé par "." (ex. "character.")
+	From        time.Time
+	To          time.Time
+	BeforeID    int64 // Pagination : entrées d'ID inférieur uniquement
+	Limit       int
+}
+
+// AuditSnapshot sérialise un état pour les champs Before et After. Retourne nil si v est nil.
+func AuditSnapshot(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+const auditColumns = `id, actor_id, action, user_id, character_id, before, after, ip_address, created_at`
+
+// AppendAuditEntry ajoute une entrée au journal d'audit.
+func (db *PostgresDB) AppendAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	return appendAuditEntry(ctx, db.pool, entry)
+}
+
+// auditRepository écrit dans le journal d'audit au sein d'une transaction de WithTx.
+type auditRepository struct {
+	q querier
+}
+
+// AppendAuditEntry ajoute une entrée au journal d'audit, annulée avec la transaction.
+func (r auditRepository) AppendAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	return appendAuditEntry(ctx, r.q, entry)
+}
+
+func appendAuditEntry(ctx context.Context, q querier, entry *AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, user_id, character_id, before, after, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	err := q.QueryRow(ctx, query,
+		entry.ActorID, entry.Action, entry.UserID, entry.CharacterID,
+		nullJSON(entry.Before), nullJSON(entry.After), entry.IPAddress,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("écriture du journal d'audit: %w", err)
+	}
+	return nil
+}
+
+// QueryAuditLog recherche dans le journal d'audit, les entrées les plus récentes d'abord.
+func (db *PostgresDB) QueryAuditLog(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != nil {
+		where("user_id = $%d", *filter.UserID)
+	}
+	if filter.CharacterID != nil {
+		where("character_id = $%d", *filter.CharacterID)
+	}
+	if strings.HasSuffix(filter.Action, ".") {
+		where("action LIKE $%d || '%%'", filter.Action)
+	} else if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d", filter.To)
+	}
+	if filter.BeforeID > 0 {
+		where("id < $%d", filter.BeforeID)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditQueryLimit
+	}
+	if limit > maxAuditQueryLimit {
+		limit = maxAuditQueryLimit
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` ORDER BY id DESC LIMIT $%d`, len(args))
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	rows, err := db.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("lecture du journal d'audit: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("lecture du journal d'audit: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, rows.Err()
+}
+
+// scanAuditEntry lit une ligne sélectionnée avec auditColumns.
+func scanAuditEntry(row pgx.Row) (*AuditEntry, error) {
+	var e AuditEntry
+	var before, after []byte
+	err := row.Scan(&e.ID, &e.ActorID, &e.Action, &e.UserID, &e.CharacterID, &before, &after, &e.IPAddress, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	e.Before, e.After = before, after
+	return &e, nil
+}
+
+// nullJSON convertit un état vide en NULL plutôt qu'en JSON invalide.
+func nullJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -1,238 +1,514 @@
 package database
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/flumen/flumen_server/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+var (
+	// ErrNameTaken est retourné quand le nom de personnage est déjà utilisé (insensible à la casse).
+	ErrNameTaken = errors.New("nom de personnage déjà utilisé")
+	// ErrCharacterLimit est retourné quand le compte a déjà MaxCharactersPerUser personnages.
+	ErrCharacterLimit = errors.New("limite de personnages atteinte")
+	// ErrInvalidClass est retourné pour une classe de personnage inconnue.
+	ErrInvalidClass = errors.New("classe invalide")
+	// ErrVersionConflict est retourné quand le personnage a été modifié depuis sa lecture.
+	ErrVersionConflict = errors.New("personnage modifié par une autre opération")
+	// ErrInvalidPosition est retourné pour une position hors des limites du monde ou de la map (models.ValidPosition).
+	ErrInvalidPosition = errors.New("position invalide")
+)
+
+// DefaultDeleteGracePeriod est le délai pendant lequel un personnage supprimé reste restaurable.
+const DefaultDeleteGracePeriod = 7 * 24 * time.Hour
+
+// characterColumns sont les colonnes lues par scanCharacter, dans l'ordre.
+const characterColumns = `id, user_id, name, class, level, vitality, wisdom, strength, intelligence, chance, agility, experience, map_x, map_y, pos_x, pos_y, created_at, updated_at, last_login, deleted_at, version`
+
 // CharacterRepository gère les opérations sur les personnages
 type CharacterRepository struct {
-	db *sql.DB
+	q           querier // Pool, ou transaction pour les repositories de WithTx
+	gracePeriod time.Duration
 }
 
-// NewCharacterRepository crée un nouveau repository pour les personnages
-func NewCharacterRepository(db *sql.DB) *CharacterRepository {
-	return &CharacterRepository{db: db}
+// NewCharacterRepository crée un nouveau repository pour les personnages.
+// gracePeriod est le délai de restauration après suppression (DefaultDeleteGracePeriod si nul).
+func NewCharacterRepository(db *PostgresDB, gracePeriod time.Duration) *CharacterRepository {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultDeleteGracePeriod
+	}
+	return &CharacterRepository{q: db.pool, gracePeriod: gracePeriod}
 }
 
-// CreateCharacter crée un nouveau personnage
-func (r *CharacterRepository) CreateCharacter(userID int, req models.CreateCharacterRequest) (*models.Character, error) {
-	// Vérifier que le nom n'est pas déjà pris
-	exists, err := r.CharacterNameExists(req.Name)
-	if err != nil {
-		return nil, fmt.Errorf("erreur lors de la vérification du nom: %w", err)
-	}
-	if exists {
-		return nil, fmt.Errorf("ce nom de personnage est déjà utilisé")
-	}
+// withTx retourne une copie du repository liée à la transaction tx.
+func (r *CharacterRepository) withTx(tx pgx.Tx) *CharacterRepository {
+	return &CharacterRepository{q: tx, gracePeriod: r.gracePeriod}
+}
 
-	// Vérifier que l'utilisateur n'a pas déjà 5 personnages (limite Dofus)
-	count, err := r.GetCharacterCountByUser(userID)
+// CreateCharacter crée un nouveau personnage avec les sorts de départ de sa classe (ClassInfo.StartingSpells).
+// Dans une transaction de WithTx, l'opération utilise un savepoint.
+// La ligne de l'utilisateur est verrouillée pendant la transaction : les créations concurrentes
+// d'un même compte sont sérialisées et ne peuvent pas dépasser MaxCharactersPerUser.
+// L'unicité du nom est garantie par l'index idx_characters_name_lower, qui couvre aussi les
+// personnages supprimés : leur nom reste réservé jusqu'à la purge.
+func (r *CharacterRepository) CreateCharacter(ctx context.Context, userID int, req models.CreateCharacterRequest) (*models.Character, error) {
+	character, err := newCharacter(userID, req)
 	if err != nil {
-		return nil, fmt.Errorf("erreur lors de la vérification du nombre de personnages: %w", err)
-	}
-	if count >= 5 {
-		return nil, fmt.Errorf("vous avez déjà atteint la limite de 5 personnages")
+		return nil, err
 	}
 
-	// Récupérer les stats de base de la classe
-	classInfo := models.GetClassInfo(req.Class)
-	if classInfo.ID == "" {
-		return nil, fmt.Errorf("classe invalide: %s", req.Class)
-	}
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
 
-	// Créer le personnage avec les stats de base
-	character := &models.Character{
-		UserID:       userID,
-		Name:         req.Name,
-		Class:        req.Class,
-		Level:        1,
-		Vitality:     classInfo.BaseStats.Vitality,
-		Wisdom:       classInfo.BaseStats.Wisdom,
-		Strength:     classInfo.BaseStats.Strength,
-		Intelligence: classInfo.BaseStats.Intelligence,
-		Chance:       classInfo.BaseStats.Chance,
-		Agility:      classInfo.BaseStats.Agility,
-		Experience:   0,
-		MapX:         0, // Position de départ
-		MapY:         0,
-		PosX:         15, // Centre de la map
-		PosY:         15,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
-		LastLogin:    time.Now(),
+	tx, err := r.q.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("création du personnage: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	// Calculer les stats dérivées
-	character.CalculateStats()
+	if err := lockCharacterSlots(ctx, tx, userID); err != nil {
+		return nil, err
+	}
 
 	// Insérer en base de données
 	query := `
 		INSERT INTO characters (user_id, name, class, level, vitality, wisdom, strength, intelligence, chance, agility, experience, map_x, map_y, pos_x, pos_y, created_at, updated_at, last_login)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
-		RETURNING id
+		RETURNING id, version
 	`
-
-	err = r.db.QueryRow(
-		query,
+	err = tx.QueryRow(
+		ctx, query,
 		character.UserID, character.Name, character.Class, character.Level,
 		character.Vitality, character.Wisdom, character.Strength, character.Intelligence,
 		character.Chance, character.Agility, character.Experience,
 		character.MapX, character.MapY, character.PosX, character.PosY,
 		character.CreatedAt, character.UpdatedAt, character.LastLogin,
-	).Scan(&character.ID)
-
+	).Scan(&character.ID, &character.Version)
 	if err != nil {
-		return nil, fmt.Errorf("erreur lors de la création du personnage: %w", err)
+		return nil, characterConstraintError(err)
+	}
+
+	// Sorts de départ de la classe, dans la même transaction
+	if err := learnStartingSpells(ctx, tx, character); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("création du personnage: %w", err)
 	}
 
 	return character, nil
 }
 
-// GetCharactersByUser récupère tous les personnages d'un utilisateur
-func (r *CharacterRepository) GetCharactersByUser(userID int) ([]models.Character, error) {
+// GetCharactersByUser récupère les personnages actifs d'un utilisateur
+func (r *CharacterRepository) GetCharactersByUser(ctx context.Context, userID int) ([]models.Character, error) {
 	query := `
-		SELECT id, user_id, name, class, level, vitality, wisdom, strength, intelligence, chance, agility, experience, map_x, map_y, pos_x, pos_y, created_at, updated_at, last_login
+		SELECT ` + characterColumns + `
 		FROM characters
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 		ORDER BY last_login DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	rows, err := r.q.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("récupération des personnages: %w", err)
+	}
+	return r.collectCharacters(rows)
+}
+
+// GetDeletedCharactersByUser récupère les personnages supprimés encore restaurables, avec leur date de purge.
+func (r *CharacterRepository) GetDeletedCharactersByUser(ctx context.Context, userID int) ([]models.Character, error) {
+	query := `
+		SELECT ` + characterColumns + `
+		FROM characters
+		WHERE user_id = $1 AND deleted_at > $2
+		ORDER BY deleted_at DESC
+	`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	rows, err := r.q.Query(ctx, query, userID, time.Now().Add(-r.gracePeriod))
 	if err != nil {
-		return nil, fmt.Errorf("erreur lors de la récupération des personnages: %w", err)
+		return nil, fmt.Errorf("récupération des personnages supprimés: %w", err)
 	}
+	return r.collectCharacters(rows)
+}
+
+// collectCharacters lit toutes les lignes et renseigne la date de purge des personnages supprimés.
+func (r *CharacterRepository) collectCharacters(rows pgx.Rows) ([]models.Character, error) {
 	defer rows.Close()
 
 	var characters []models.Character
 	for rows.Next() {
-		var char models.Character
-		err := rows.Scan(
-			&char.ID, &char.UserID, &char.Name, &char.Class, &char.Level,
-			&char.Vitality, &char.Wisdom, &char.Strength, &char.Intelligence,
-			&char.Chance, &char.Agility, &char.Experience,
-			&char.MapX, &char.MapY, &char.PosX, &char.PosY,
-			&char.CreatedAt, &char.UpdatedAt, &char.LastLogin,
-		)
+		char, err := scanCharacter(rows)
 		if err != nil {
-			return nil, fmt.Errorf("erreur lors du scan du personnage: %w", err)
+			return nil, fmt.Errorf("lecture du personnage: %w", err)
 		}
-
-		// Calculer les stats dérivées
-		char.CalculateStats()
-		characters = append(characters, char)
+		char.SetPurgeAt(r.gracePeriod)
+		characters = append(characters, *char)
 	}
 
-	return characters, nil
+	return characters, rows.Err()
 }
 
-// GetCharacterByID récupère un personnage par son ID
-func (r *CharacterRepository) GetCharacterByID(characterID int) (*models.Character, error) {
+// GetCharacterByID récupère un personnage actif par son ID
+func (r *CharacterRepository) GetCharacterByID(ctx context.Context, characterID int) (*models.Character, error) {
 	query := `
-		SELECT id, user_id, name, class, level, vitality, wisdom, strength, intelligence, chance, agility, experience, map_x, map_y, pos_x, pos_y, created_at, updated_at, last_login
+		SELECT ` + characterColumns + `
 		FROM characters
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	var char models.Character
-	err := r.db.QueryRow(query, characterID).Scan(
-		&char.ID, &char.UserID, &char.Name, &char.Class, &char.Level,
-		&char.Vitality, &char.Wisdom, &char.Strength, &char.Intelligence,
-		&char.Chance, &char.Agility, &char.Experience,
-		&char.MapX, &char.MapY, &char.PosX, &char.PosY,
-		&char.CreatedAt, &char.UpdatedAt, &char.LastLogin,
-	)
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
 
+	char, err := scanCharacter(r.q.QueryRow(ctx, query, characterID))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("personnage non trouvé")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
 		}
-		return nil, fmt.Errorf("erreur lors de la récupération du personnage: %w", err)
+		return nil, fmt.Errorf("récupération du personnage: %w", err)
 	}
 
-	// Calculer les stats dérivées
-	char.CalculateStats()
-	return &char, nil
+	return char, nil
 }
 
 // UpdateCharacterPosition met à jour la position d'un personnage
-func (r *CharacterRepository) UpdateCharacterPosition(characterID int, mapX, mapY, posX, posY int) error {
+func (r *CharacterRepository) UpdateCharacterPosition(ctx context.Context, characterID int, mapX, mapY, posX, posY int) error {
 	query := `
 		UPDATE characters
 		SET map_x = $1, map_y = $2, pos_x = $3, pos_y = $4, updated_at = $5
-		WHERE id = $6
+		WHERE id = $6 AND deleted_at IS NULL
 	`
 
-	_, err := r.db.Exec(query, mapX, mapY, posX, posY, time.Now(), characterID)
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tag, err := r.q.Exec(ctx, query, mapX, mapY, posX, posY, time.Now(), characterID)
 	if err != nil {
-		return fmt.Errorf("erreur lors de la mise à jour de la position: %w", err)
+		return fmt.Errorf("mise à jour de la position: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateCharacterStats enregistre le niveau, les stats et l'expérience du personnage si sa version n'a pas
+// changé depuis sa lecture, et incrémente character.Version. Retourne ErrVersionConflict si une autre
+// opération l'a modifié entre temps (relire puis réessayer, voir ModifyCharacter) et ErrNotFound s'il
+// n'existe plus.
+func (r *CharacterRepository) UpdateCharacterStats(ctx context.Context, character *models.Character) error {
+	query := `
+		UPDATE characters
+		SET level = $1, vitality = $2, wisdom = $3, strength = $4, intelligence = $5,
+			chance = $6, agility = $7, experience = $8, updated_at = $9, version = version + 1
+		WHERE id = $10 AND version = $11 AND deleted_at IS NULL
+		RETURNING version, updated_at
+	`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	err := r.q.QueryRow(
+		ctx, query,
+		character.Level, character.Vitality, character.Wisdom, character.Strength, character.Intelligence,
+		character.Chance, character.Agility, character.Experience, time.Now(),
+		character.ID, character.Version,
+	).Scan(&character.Version, &character.UpdatedAt)
+	if err == nil {
+		character.CalculateStats()
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("mise à jour des stats: %w", err)
+	}
+
+	// Aucune ligne : personnage disparu ou version périmée
+	var exists bool
+	query = `SELECT EXISTS(SELECT 1 FROM characters WHERE id = $1 AND deleted_at IS NULL)`
+	if err := r.q.QueryRow(ctx, query, character.ID).Scan(&exists); err != nil {
+		return fmt.Errorf("mise à jour des stats: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+// UpdateCharacterPositions met à jour un lot de positions en une seule requête.
+// Les personnages supprimés entre temps sont ignorés.
+func (r *CharacterRepository) UpdateCharacterPositions(ctx context.Context, positions []CharacterPosition) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, len(positions))
+	mapX := make([]int32, len(positions))
+	mapY := make([]int32, len(positions))
+	posX := make([]int32, len(positions))
+	posY := make([]int32, len(positions))
+	for i, pos := range positions {
+		ids[i] = int32(pos.CharacterID)
+		mapX[i], mapY[i] = int32(pos.MapX), int32(pos.MapY)
+		posX[i], posY[i] = int32(pos.PosX), int32(pos.PosY)
+	}
+
+	query := `
+		UPDATE characters AS c
+		SET map_x = v.map_x, map_y = v.map_y, pos_x = v.pos_x, pos_y = v.pos_y, updated_at = $6
+		FROM unnest($1::INTEGER[], $2::INTEGER[], $3::INTEGER[], $4::INTEGER[], $5::INTEGER[])
+			AS v(id, map_x, map_y, pos_x, pos_y)
+		WHERE c.id = v.id AND c.deleted_at IS NULL
+	`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	if _, err := r.q.Exec(ctx, query, ids, mapX, mapY, posX, posY, time.Now()); err != nil {
+		return fmt.Errorf("mise à jour des positions: %w", err)
 	}
 
 	return nil
 }
 
 // UpdateCharacterLastLogin met à jour la dernière connexion
-func (r *CharacterRepository) UpdateCharacterLastLogin(characterID int) error {
+func (r *CharacterRepository) UpdateCharacterLastLogin(ctx context.Context, characterID int) error {
 	query := `
 		UPDATE characters
-		SET last_login = $1, updated_at = $2
-		WHERE id = $3
+		SET last_login = $1, updated_at = $1
+		WHERE id = $2 AND deleted_at IS NULL
 	`
 
-	_, err := r.db.Exec(query, time.Now(), time.Now(), characterID)
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tag, err := r.q.Exec(ctx, query, time.Now(), characterID)
 	if err != nil {
-		return fmt.Errorf("erreur lors de la mise à jour de la dernière connexion: %w", err)
+		return fmt.Errorf("mise à jour de la dernière connexion: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
 	}
 
 	return nil
 }
 
-// CharacterNameExists vérifie si un nom de personnage existe déjà
-func (r *CharacterRepository) CharacterNameExists(name string) (bool, error) {
+// CharacterNameExists vérifie si un nom de personnage existe déjà, y compris parmi les personnages
+// supprimés non encore purgés (nom réservé pendant le délai de grâce)
+func (r *CharacterRepository) CharacterNameExists(ctx context.Context, name string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM characters WHERE LOWER(name) = LOWER($1))`
 
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
 	var exists bool
-	err := r.db.QueryRow(query, name).Scan(&exists)
-	if err != nil {
-		return false, fmt.Errorf("erreur lors de la vérification du nom: %w", err)
+	if err := r.q.QueryRow(ctx, query, name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("vérification du nom: %w", err)
 	}
 
 	return exists, nil
 }
 
-// GetCharacterCountByUser compte le nombre de personnages d'un utilisateur
-func (r *CharacterRepository) GetCharacterCountByUser(userID int) (int, error) {
-	query := `SELECT COUNT(*) FROM characters WHERE user_id = $1`
+// GetCharacterCountByUser compte le nombre de personnages actifs d'un utilisateur
+func (r *CharacterRepository) GetCharacterCountByUser(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM characters WHERE user_id = $1 AND deleted_at IS NULL`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
 
 	var count int
-	err := r.db.QueryRow(query, userID).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("erreur lors du comptage des personnages: %w", err)
+	if err := r.q.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("comptage des personnages: %w", err)
 	}
 
 	return count, nil
 }
 
-// DeleteCharacter supprime un personnage (soft delete possible plus tard)
-func (r *CharacterRepository) DeleteCharacter(characterID, userID int) error {
-	query := `DELETE FROM characters WHERE id = $1 AND user_id = $2`
+// DeleteCharacter marque un personnage comme supprimé : il libère un emplacement mais reste
+// restaurable pendant le délai de grâce. Retourne ErrNotFound s'il n'existe pas, est déjà supprimé
+// ou n'appartient pas à l'utilisateur.
+func (r *CharacterRepository) DeleteCharacter(ctx context.Context, characterID, userID int) error {
+	query := `
+		UPDATE characters
+		SET deleted_at = $1, updated_at = $1
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+	`
 
-	result, err := r.db.Exec(query, characterID, userID)
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tag, err := r.q.Exec(ctx, query, time.Now(), characterID, userID)
+	if err != nil {
+		return fmt.Errorf("suppression du personnage: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RestoreCharacter annule la suppression d'un personnage pendant le délai de grâce.
+// Retourne ErrNotFound si le personnage n'est pas restaurable et ErrCharacterLimit si le compte
+// n'a plus d'emplacement libre.
+func (r *CharacterRepository) RestoreCharacter(ctx context.Context, characterID, userID int) (*models.Character, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tx, err := r.q.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("erreur lors de la suppression du personnage: %w", err)
+		return nil, fmt.Errorf("restauration du personnage: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCharacterSlots(ctx, tx, userID); err != nil {
+		return nil, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	query := `
+		UPDATE characters
+		SET deleted_at = NULL, updated_at = $1
+		WHERE id = $2 AND user_id = $3 AND deleted_at > $4
+		RETURNING ` + characterColumns
+	now := time.Now()
+	char, err := scanCharacter(tx.QueryRow(ctx, query, now, characterID, userID, now.Add(-r.gracePeriod)))
 	if err != nil {
-		return fmt.Errorf("erreur lors de la vérification de la suppression: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("restauration du personnage: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("restauration du personnage: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("personnage non trouvé ou non autorisé")
+	return char, nil
+}
+
+// PurgeDeletedCharacters supprime définitivement les personnages dont le délai de grâce est écoulé
+// et libère leurs noms. Retourne le nombre de personnages purgés.
+func (r *CharacterRepository) PurgeDeletedCharacters(ctx context.Context) (int64, error) {
+	query := `DELETE FROM characters WHERE deleted_at <= $1`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tag, err := r.q.Exec(ctx, query, time.Now().Add(-r.gracePeriod))
+	if err != nil {
+		return 0, fmt.Errorf("purge des personnages supprimés: %w", err)
 	}
 
+	return tag.RowsAffected(), nil
+}
+
+// lockCharacterSlots verrouille la ligne de l'utilisateur jusqu'à la fin de la transaction et vérifie
+// qu'il lui reste un emplacement (limite Dofus, personnages supprimés exclus).
+func lockCharacterSlots(ctx context.Context, tx pgx.Tx, userID int) error {
+	var count int
+	query := `
+		SELECT (SELECT COUNT(*) FROM characters WHERE user_id = u.id AND deleted_at IS NULL)
+		FROM users u
+		WHERE u.id = $1
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("comptage des personnages: %w", err)
+	}
+	if count >= MaxCharactersPerUser {
+		return ErrCharacterLimit
+	}
 	return nil
 }
+
+// characterConstraintError traduit les violations de contraintes de la table characters en erreurs typées.
+func characterConstraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			if strings.Contains(pgErr.ConstraintName, "name") {
+				return ErrNameTaken
+			}
+		case foreignKeyViolation:
+			// Classe absente de character_classes, ou utilisateur inexistant
+			if strings.Contains(pgErr.ConstraintName, "class") {
+				return ErrInvalidClass
+			}
+			return ErrNotFound
+		}
+	}
+	return fmt.Errorf("création du personnage: %w", err)
+}
+
+// scanCharacter lit une ligne sélectionnée avec characterColumns et calcule les stats dérivées.
+func scanCharacter(row pgx.Row) (*models.Character, error) {
+	var char models.Character
+	err := row.Scan(
+		&char.ID, &char.UserID, &char.Name, &char.Class, &char.Level,
+		&char.Vitality, &char.Wisdom, &char.Strength, &char.Intelligence,
+		&char.Chance, &char.Agility, &char.Experience,
+		&char.MapX, &char.MapY, &char.PosX, &char.PosY,
+		&char.CreatedAt, &char.UpdatedAt, &char.LastLogin, &char.DeletedAt, &char.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculer les stats dérivées
+	char.CalculateStats()
+	return &char, nil
+}
+
+// newCharacter construit un personnage de niveau 1 avec les stats de base de sa classe.
+func newCharacter(userID int, req models.CreateCharacterRequest) (*models.Character, error) {
+	// Récupérer les stats de base de la classe
+	classInfo := models.GetClassInfo(req.Class)
+	if classInfo.ID == "" {
+		return nil, ErrInvalidClass
+	}
+
+	// Créer le personnage avec les stats de base
+	now := time.Now()
+	character := &models.Character{
+		UserID:       userID,
+		Name:         req.Name,
+		Class:        req.Class,
+		Level:        1,
+		Vitality:     classInfo.BaseStats.Vitality,
+		Wisdom:       classInfo.BaseStats.Wisdom,
+		Strength:     classInfo.BaseStats.Strength,
+		Intelligence: classInfo.BaseStats.Intelligence,
+		Chance:       classInfo.BaseStats.Chance,
+		Agility:      classInfo.BaseStats.Agility,
+		Experience:   0,
+		MapX:         classInfo.StartMap.MapX, // Position de départ de la classe
+		MapY:         classInfo.StartMap.MapY,
+		PosX:         classInfo.StartMap.PosX,
+		PosY:         classInfo.StartMap.PosY,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		LastLogin:    now,
+		Version:      1,
+	}
+
+	// Calculer les stats dérivées
+	character.CalculateStats()
+
+	return character, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/models"
+)
+
+// maxModifyAttempts est le nombre de tentatives de ModifyCharacter avant d'abandonner sur un conflit.
+const maxModifyAttempts = 5
+
+// modifyRetryDelay est l'attente avant la première nouvelle tentative, doublée à chaque conflit.
+const modifyRetryDelay = 5 * time.Millisecond
+
+// ModifyCharacter lit le personnage, lui applique mutate puis enregistre ses stats et son expérience
+// avec UpdateCharacterStats. En cas de ErrVersionConflict l'opération est rejouée sur une relecture,
+// si bien qu'une récompense de combat et une répartition de points concurrentes s'appliquent toutes
+// les deux. mutate peut donc être appelée plusieurs fois et ne doit modifier que le personnage reçu ;
+// une erreur de mutate interrompt l'opération sans rien écrire.
+//
+//	character, err := database.ModifyCharacter(ctx, store, id, func(c *models.Character) error {
+//		c.Experience += reward
+//		for c.CanLevelUp() {
+//			c.LevelUp()
+//		}
+//		return nil
+//	})
+func ModifyCharacter(ctx context.Context, store CharacterStore, characterID int, mutate func(*models.Character) error) (*models.Character, error) {
+	delay := modifyRetryDelay
+	for attempt := 1; ; attempt++ {
+		character, err := ModifyCharacterOnce(ctx, store, characterID, mutate)
+		if !errors.Is(err, ErrVersionConflict) {
+			return character, err
+		}
+		if attempt == maxModifyAttempts {
+			return nil, fmt.Errorf("personnage %d après %d tentatives: %w", characterID, attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// ModifyCharacterOnce est ModifyCharacter sans nouvelle tentative : un conflit retourne ErrVersionConflict.
+// C'est la variante à utiliser dans Transactor.WithTx, qui rejoue déjà toute la transaction sur conflit.
+func ModifyCharacterOnce(ctx context.Context, store CharacterStore, characterID int, mutate func(*models.Character) error) (*models.Character, error) {
+	character, err := store.GetCharacterByID(ctx, characterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := mutate(character); err != nil {
+		return nil, err
+	}
+	if err := store.UpdateCharacterStats(ctx, character); err != nil {
+		return nil, err
+	}
+	return character, nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/flumen/flumen_server/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// LoadClassRegistry charge les classes jouables de character_classes et vérifie que leurs sorts de départ
+// existent et sont accessibles à la classe au niveau 1. À appeler au démarrage : une erreur signifie des
+// données de référence incohérentes.
+func (db *PostgresDB) LoadClassRegistry(ctx context.Context) (*models.ClassRegistry, error) {
+	query := `
+		SELECT id, name, description, icon_path,
+		       base_vitality, base_wisdom, base_strength, base_intelligence, base_chance, base_agility,
+		       growth_vitality, growth_wisdom, growth_strength, growth_intelligence, growth_chance, growth_agility,
+		       starting_spells, start_map_x, start_map_y, start_pos_x, start_pos_y
+		FROM character_classes
+		ORDER BY sort_order, id
+	`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	rows, err := db.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("chargement des classes: %w", err)
+	}
+	defer rows.Close()
+
+	var classes []models.ClassInfo
+	for rows.Next() {
+		var class models.ClassInfo
+		err := rows.Scan(
+			&class.ID, &class.Name, &class.Description, &class.IconPath,
+			&class.BaseStats.Vitality, &class.BaseStats.Wisdom, &class.BaseStats.Strength,
+			&class.BaseStats.Intelligence, &class.BaseStats.Chance, &class.BaseStats.Agility,
+			&class.Growth.Vitality, &class.Growth.Wisdom, &class.Growth.Strength,
+			&class.Growth.Intelligence, &class.Growth.Chance, &class.Growth.Agility,
+			&class.StartingSpells,
+			&class.StartMap.MapX, &class.StartMap.MapY, &class.StartMap.PosX, &class.StartMap.PosY,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("lecture de la classe: %w", err)
+		}
+		classes = append(classes, class)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("chargement des classes: %w", err)
+	}
+
+	registry, err := models.NewClassRegistry(classes)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.checkStartingSpells(ctx, registry); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// checkStartingSpells vérifie les sorts de départ de chaque classe dans spell_templates.
+func (db *PostgresDB) checkStartingSpells(ctx context.Context, registry *models.ClassRegistry) error {
+	query := `SELECT COALESCE(class, ''), min_level FROM spell_templates WHERE id = $1`
+
+	var errs []error
+	for _, class := range registry.All() {
+		for _, spellID := range class.StartingSpells {
+			var template models.SpellTemplate
+			err := db.pool.QueryRow(ctx, query, spellID).Scan(&template.Class, &template.MinLevel)
+			if errors.Is(err, pgx.ErrNoRows) {
+				errs = append(errs, fmt.Errorf("classe %q: sort de départ %q: %w", class.ID, spellID, ErrSpellNotFound))
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("classe %q: sort de départ %q: %w", class.ID, spellID, err))
+				continue
+			}
+			if !template.LearnableBy(class.ID, 1) {
+				errs = append(errs, fmt.Errorf("classe %q: sort de départ %q non accessible au niveau 1", class.ID, spellID))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// startingSpells retourne les sorts appris à la création d'un personnage de la classe : uniquement les
+// sorts de départ du registre, pour les deux stores. Les autres sorts de la classe s'apprennent ensuite
+// avec LearnSpell ou LearnClassSpells.
+func startingSpells(class models.CharacterClass) []string {
+	return models.GetClassInfo(class).StartingSpells
+}
+
+// learnStartingSpells insère les sorts de départ d'un personnage qui vient d'être créé.
+func learnStartingSpells(ctx context.Context, q querier, character *models.Character) error {
+	spellIDs := startingSpells(character.Class)
+	if len(spellIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO character_spells (character_id, spell_id, level_learned, spell_level, xp)
+		SELECT $1, s.id, 1, 1, 0
+		FROM spell_templates s
+		WHERE s.id = ANY($2)
+		ON CONFLICT (character_id, spell_id) DO NOTHING
+	`
+
+	if _, err := q.Exec(ctx, query, character.ID, spellIDs); err != nil {
+		return fmt.Errorf("apprentissage des sorts de départ: %w", err)
+	}
+	return nil
+}
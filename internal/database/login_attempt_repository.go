@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Causes d'échec enregistrées dans le journal des connexions.
+const (
+	LoginReasonUnknownUser = "unknown_user"
+	LoginReasonBadPassword = "bad_password"
+	LoginReasonBadOTP      = "bad_otp"
+	LoginReasonThrottled   = "throttled"
+	LoginReasonLocked      = "locked"
+)
+
+type LoginAttempt struct {
+	ID         int64     `db:"id"`
+	UserID     *int      `db:"user_id"`
+	Identifier string    `db:"identifier"`
+	IPAddress  string    `db:"ip_address"`
+	Success    bool      `db:"success"`
+	Reason     string    `db:"reason"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// RecordLoginAttempt ajoute une tentative de connexion au journal.
+func (db *PostgresDB) RecordLoginAttempt(ctx context.Context, attempt *LoginAttempt) error {
+	query := `
+		INSERT INTO login_attempts (user_id, identifier, ip_address, success, reason)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at`
+
+	return db.pool.QueryRow(ctx, query, attempt.UserID, attempt.Identifier, attempt.IPAddress, attempt.Success, attempt.Reason).Scan(&attempt.ID, &attempt.CreatedAt)
+}
+
+// GetRecentLoginAttempts retourne les dernières tentatives de connexion d'un utilisateur.
+func (db *PostgresDB) GetRecentLoginAttempts(ctx context.Context, userID int, limit int) ([]LoginAttempt, error) {
+	query := `
+		SELECT id, user_id, identifier, ip_address, success, COALESCE(reason, ''), created_at
+		FROM login_attempts
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`
+
+	rows, err := db.pool.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []LoginAttempt
+	for rows.Next() {
+		var attempt LoginAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.UserID, &attempt.Identifier, &attempt.IPAddress, &attempt.Success, &attempt.Reason, &attempt.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
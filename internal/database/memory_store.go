@@ -0,0 +1,567 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/models"
+)
+
+// MemoryStore implémente UserStore, CharacterStore, SpellStore et Transactor en mémoire, pour les tests des handlers
+// et de la logique de jeu. Il applique les mêmes contraintes que PostgreSQL : usernames, emails
+// et noms de personnages uniques sans tenir compte de la casse, limite de personnages par compte
+// et suppression en cascade des personnages avec leur compte et des sorts avec leur personnage.
+// Les sorts disponibles sont déclarés avec SetSpellTemplates. Les personnages supprimés restent
+// restaurables pendant DefaultDeleteGracePeriod, mesuré avec l'horloge de SetClock.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	users       map[int]*User
+	characters  map[int]*models.Character
+	nextUserID  int
+	nextCharID  int
+	gracePeriod time.Duration
+	audit       []AuditEntry
+	templates   map[string]models.SpellTemplate
+	spells      map[int]map[string]models.CharacterSpell // Par personnage puis par sort
+	now         func() time.Time
+}
+
+// NewMemoryStore crée un MemoryStore vide.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:       make(map[int]*User),
+		characters:  make(map[int]*models.Character),
+		nextUserID:  1,
+		nextCharID:  1,
+		gracePeriod: DefaultDeleteGracePeriod,
+		templates:   make(map[string]models.SpellTemplate),
+		spells:      make(map[int]map[string]models.CharacterSpell),
+		now:         time.Now,
+	}
+}
+
+// SetClock remplace l'horloge du store (dates de suppression, délai de grâce, purge) pour les tests.
+func (m *MemoryStore) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+// SetSpellTemplates déclare les sorts disponibles (équivalent de spell_templates).
+func (m *MemoryStore) SetSpellTemplates(templates ...models.SpellTemplate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, template := range templates {
+		m.templates[template.ID] = template
+	}
+}
+
+// CreateUser enregistre un utilisateur et renseigne son ID et ses dates.
+func (m *MemoryStore) CreateUser(ctx context.Context, user *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if strings.EqualFold(existing.Username, user.Username) {
+			return ErrUsernameTaken
+		}
+		if strings.EqualFold(existing.Email, user.Email) {
+			return ErrEmailTaken
+		}
+	}
+
+	now := m.now()
+	user.ID = m.nextUserID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	m.nextUserID++
+
+	stored := *user
+	m.users[user.ID] = &stored
+	return nil
+}
+
+// GetUserByID retourne l'utilisateur, ou ErrNotFound.
+func (m *MemoryStore) GetUserByID(ctx context.Context, id int) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if user, ok := m.users[id]; ok {
+		found := *user
+		return &found, nil
+	}
+	return nil, ErrNotFound
+}
+
+// GetUserByEmail retourne l'utilisateur sans tenir compte de la casse, ou ErrNotFound.
+func (m *MemoryStore) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	return m.findUser(func(u *User) bool { return strings.EqualFold(u.Email, email) })
+}
+
+// GetUserByUsername retourne l'utilisateur sans tenir compte de la casse, ou ErrNotFound.
+func (m *MemoryStore) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	return m.findUser(func(u *User) bool { return strings.EqualFold(u.Username, username) })
+}
+
+func (m *MemoryStore) findUser(match func(u *User) bool) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if match(user) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeleteUser supprime l'utilisateur et ses personnages.
+func (m *MemoryStore) DeleteUser(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, id)
+	for charID, character := range m.characters {
+		if character.UserID == id {
+			delete(m.characters, charID)
+			delete(m.spells, charID)
+		}
+	}
+	return nil
+}
+
+// CreateCharacter crée un nouveau personnage
+func (m *MemoryStore) CreateCharacter(ctx context.Context, userID int, req models.CreateCharacterRequest) (*models.Character, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, fmt.Errorf("création du personnage: utilisateur %d: %w", userID, ErrNotFound)
+	}
+	if m.nameExists(req.Name) {
+		return nil, ErrNameTaken
+	}
+	if m.countByUser(userID) >= MaxCharactersPerUser {
+		return nil, ErrCharacterLimit
+	}
+
+	character, err := newCharacter(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	character.ID = m.nextCharID
+	m.nextCharID++
+
+	stored := *character
+	m.characters[character.ID] = &stored
+	m.learnStartingSpells(&stored)
+	return character, nil
+}
+
+// GetCharactersByUser récupère les personnages actifs d'un utilisateur
+func (m *MemoryStore) GetCharactersByUser(ctx context.Context, userID int) ([]models.Character, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var characters []models.Character
+	for _, character := range m.characters {
+		if character.UserID == userID && character.DeletedAt == nil {
+			characters = append(characters, *character)
+		}
+	}
+	sort.Slice(characters, func(i, j int) bool { return characters[i].LastLogin.After(characters[j].LastLogin) })
+	return characters, nil
+}
+
+// GetDeletedCharactersByUser récupère les personnages supprimés encore restaurables
+func (m *MemoryStore) GetDeletedCharactersByUser(ctx context.Context, userID int) ([]models.Character, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var characters []models.Character
+	for _, character := range m.characters {
+		if character.UserID == userID && m.restorable(character, m.now()) {
+			found := *character
+			found.SetPurgeAt(m.gracePeriod)
+			characters = append(characters, found)
+		}
+	}
+	sort.Slice(characters, func(i, j int) bool { return characters[i].DeletedAt.After(*characters[j].DeletedAt) })
+	return characters, nil
+}
+
+// GetCharacterByID récupère un personnage actif par son ID
+func (m *MemoryStore) GetCharacterByID(ctx context.Context, characterID int) (*models.Character, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	character, ok := m.characters[characterID]
+	if !ok || character.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	found := *character
+	return &found, nil
+}
+
+// UpdateCharacterPosition met à jour la position d'un personnage
+func (m *MemoryStore) UpdateCharacterPosition(ctx context.Context, characterID int, mapX, mapY, posX, posY int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	character, ok := m.characters[characterID]
+	if !ok || character.DeletedAt != nil {
+		return ErrNotFound
+	}
+	character.MapX, character.MapY = mapX, mapY
+	character.PosX, character.PosY = posX, posY
+	character.UpdatedAt = m.now()
+	return nil
+}
+
+// UpdateCharacterStats enregistre les stats et l'expérience si la version n'a pas changé
+func (m *MemoryStore) UpdateCharacterStats(ctx context.Context, character *models.Character) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.characters[character.ID]
+	if !ok || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if stored.Version != character.Version {
+		return ErrVersionConflict
+	}
+
+	stored.Level = character.Level
+	stored.Vitality, stored.Wisdom = character.Vitality, character.Wisdom
+	stored.Strength, stored.Intelligence = character.Strength, character.Intelligence
+	stored.Chance, stored.Agility = character.Chance, character.Agility
+	stored.Experience = character.Experience
+	stored.UpdatedAt = m.now()
+	stored.Version++
+	stored.CalculateStats()
+
+	character.Version = stored.Version
+	character.UpdatedAt = stored.UpdatedAt
+	character.CalculateStats()
+	return nil
+}
+
+// UpdateCharacterPositions met à jour un lot de positions, en ignorant les personnages supprimés
+func (m *MemoryStore) UpdateCharacterPositions(ctx context.Context, positions []CharacterPosition) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for _, pos := range positions {
+		character, ok := m.characters[pos.CharacterID]
+		if !ok || character.DeletedAt != nil {
+			continue
+		}
+		character.MapX, character.MapY = pos.MapX, pos.MapY
+		character.PosX, character.PosY = pos.PosX, pos.PosY
+		character.UpdatedAt = now
+	}
+	return nil
+}
+
+// UpdateCharacterLastLogin met à jour la dernière connexion
+func (m *MemoryStore) UpdateCharacterLastLogin(ctx context.Context, characterID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	character, ok := m.characters[characterID]
+	if !ok || character.DeletedAt != nil {
+		return ErrNotFound
+	}
+	now := m.now()
+	character.LastLogin = now
+	character.UpdatedAt = now
+	return nil
+}
+
+// CharacterNameExists vérifie si un nom de personnage existe déjà
+func (m *MemoryStore) CharacterNameExists(ctx context.Context, name string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.nameExists(name), nil
+}
+
+// GetCharacterCountByUser compte le nombre de personnages d'un utilisateur
+func (m *MemoryStore) GetCharacterCountByUser(ctx context.Context, userID int) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.countByUser(userID), nil
+}
+
+// DeleteCharacter marque un personnage comme supprimé, restaurable pendant le délai de grâce
+func (m *MemoryStore) DeleteCharacter(ctx context.Context, characterID, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	character, ok := m.characters[characterID]
+	if !ok || character.UserID != userID || character.DeletedAt != nil {
+		return ErrNotFound
+	}
+	now := m.now()
+	character.DeletedAt = &now
+	character.UpdatedAt = now
+	return nil
+}
+
+// RestoreCharacter annule la suppression d'un personnage pendant le délai de grâce
+func (m *MemoryStore) RestoreCharacter(ctx context.Context, characterID, userID int) (*models.Character, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	character, ok := m.characters[characterID]
+	if !ok || character.UserID != userID || !m.restorable(character, now) {
+		return nil, ErrNotFound
+	}
+	if m.countByUser(userID) >= MaxCharactersPerUser {
+		return nil, ErrCharacterLimit
+	}
+	character.DeletedAt = nil
+	character.UpdatedAt = now
+
+	found := *character
+	return &found, nil
+}
+
+// PurgeDeletedCharacters supprime définitivement les personnages dont le délai de grâce est écoulé
+func (m *MemoryStore) PurgeDeletedCharacters(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	now := m.now()
+	for charID, character := range m.characters {
+		if character.DeletedAt != nil && !m.restorable(character, now) {
+			delete(m.characters, charID)
+			delete(m.spells, charID)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MemoryStore) restorable(character *models.Character, now time.Time) bool {
+	return character.DeletedAt != nil && character.DeletedAt.After(now.Add(-m.gracePeriod))
+}
+
+func (m *MemoryStore) nameExists(name string) bool {
+	for _, character := range m.characters {
+		if strings.EqualFold(character.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// LearnSpell fait apprendre un sort au personnage, au niveau 1
+func (m *MemoryStore) LearnSpell(ctx context.Context, characterID int, spellID string) (*models.CharacterSpell, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	character, ok := m.characters[characterID]
+	if !ok || character.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	template, ok := m.templates[spellID]
+	if !ok {
+		return nil, ErrSpellNotFound
+	}
+	if !template.LearnableBy(character.Class, character.Level) {
+		return nil, ErrSpellNotLearnable
+	}
+	if _, known := m.spells[characterID][spellID]; known {
+		return nil, ErrSpellAlreadyKnown
+	}
+
+	spell := m.learn(character, template)
+	return &spell, nil
+}
+
+// LearnClassSpells fait apprendre les sorts de la classe accessibles au niveau du personnage
+func (m *MemoryStore) LearnClassSpells(ctx context.Context, characterID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	character, ok := m.characters[characterID]
+	if !ok || character.DeletedAt != nil {
+		return 0, nil
+	}
+	return m.learnClassSpells(character), nil
+}
+
+// GetCharacterSpells récupère les sorts appris par un personnage, par niveau requis
+func (m *MemoryStore) GetCharacterSpells(ctx context.Context, characterID int) ([]models.CharacterSpell, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	spells := []models.CharacterSpell{}
+	for _, spell := range m.spells[characterID] {
+		spells = append(spells, spell)
+	}
+	sort.Slice(spells, func(i, j int) bool {
+		if spells[i].MinLevel != spells[j].MinLevel {
+			return spells[i].MinLevel < spells[j].MinLevel
+		}
+		return spells[i].ID < spells[j].ID
+	})
+	return spells, nil
+}
+
+// LevelUpSpell augmente d'un niveau un sort appris
+func (m *MemoryStore) LevelUpSpell(ctx context.Context, characterID int, spellID string) (*models.CharacterSpell, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	spell, ok := m.spells[characterID][spellID]
+	if !ok {
+		return nil, ErrSpellNotKnown
+	}
+	if spell.SpellLevel >= models.MaxSpellLevel {
+		return nil, ErrSpellMaxLevel
+	}
+	spell.SpellLevel++
+	m.spells[characterID][spellID] = spell
+	return &spell, nil
+}
+
+// ForgetSpell retire un sort au personnage
+func (m *MemoryStore) ForgetSpell(ctx context.Context, characterID int, spellID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.spells[characterID][spellID]; !ok {
+		return ErrSpellNotKnown
+	}
+	delete(m.spells[characterID], spellID)
+	return nil
+}
+
+// learnClassSpells fait apprendre les sorts de la classe accessibles au niveau du personnage (LearnClassSpells).
+func (m *MemoryStore) learnClassSpells(character *models.Character) int {
+	learned := 0
+	for _, template := range m.templates {
+		if _, known := m.spells[character.ID][template.ID]; known || !template.LearnableBy(character.Class, character.Level) {
+			continue
+		}
+		m.learn(character, template)
+		learned++
+	}
+	return learned
+}
+
+// learnStartingSpells fait apprendre à un personnage qui vient d'être créé les sorts de départ de sa classe
+// déclarés avec SetSpellTemplates, comme le CharacterRepository.
+func (m *MemoryStore) learnStartingSpells(character *models.Character) {
+	for _, spellID := range startingSpells(character.Class) {
+		if template, ok := m.templates[spellID]; ok {
+			m.learn(character, template)
+		}
+	}
+}
+
+func (m *MemoryStore) learn(character *models.Character, template models.SpellTemplate) models.CharacterSpell {
+	spell := models.CharacterSpell{
+		SpellTemplate: template,
+		CharacterID:   character.ID,
+		LevelLearned:  character.Level,
+		SpellLevel:    1,
+		LearnedAt:     m.now(),
+	}
+	if m.spells[character.ID] == nil {
+		m.spells[character.ID] = make(map[string]models.CharacterSpell)
+	}
+	m.spells[character.ID][template.ID] = spell
+	return spell
+}
+
+// AppendAuditEntry ajoute une entrée au journal d'audit en mémoire
+func (m *MemoryStore) AppendAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.ID = int64(len(m.audit) + 1)
+	entry.CreatedAt = m.now()
+	m.audit = append(m.audit, *entry)
+	return nil
+}
+
+// WithTx exécute fn avec le MemoryStore comme Stores ; ses écritures sont annulées si fn retourne une erreur.
+// Contrairement à PostgreSQL il n'y a pas d'isolation : le MemoryStore ne doit pas être modifié
+// par ailleurs pendant fn.
+func (m *MemoryStore) WithTx(ctx context.Context, fn func(tx Stores) error) error {
+	return m.Savepoint(ctx, fn)
+}
+
+// Characters retourne le MemoryStore lui-même.
+func (m *MemoryStore) Characters() CharacterStore { return m }
+
+// Spells retourne le MemoryStore lui-même.
+func (m *MemoryStore) Spells() SpellStore { return m }
+
+// Audit retourne le MemoryStore lui-même.
+func (m *MemoryStore) Audit() AuditWriter { return m }
+
+// Savepoint exécute fn et restaure l'état précédent si fn retourne une erreur.
+func (m *MemoryStore) Savepoint(ctx context.Context, fn func(tx Stores) error) error {
+	restore := m.snapshot()
+	if err := fn(m); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+// snapshot copie l'état du store et retourne la fonction qui le restaure.
+func (m *MemoryStore) snapshot() (restore func()) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make(map[int]*User, len(m.users))
+	for id, user := range m.users {
+		copied := *user
+		users[id] = &copied
+	}
+	characters := make(map[int]*models.Character, len(m.characters))
+	for id, character := range m.characters {
+		copied := *character
+		characters[id] = &copied
+	}
+	spells := make(map[int]map[string]models.CharacterSpell, len(m.spells))
+	for id, known := range m.spells {
+		spells[id] = make(map[string]models.CharacterSpell, len(known))
+		for spellID, spell := range known {
+			spells[id][spellID] = spell
+		}
+	}
+	nextUserID, nextCharID, auditLen := m.nextUserID, m.nextCharID, len(m.audit)
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.users, m.characters, m.spells = users, characters, spells
+		m.nextUserID, m.nextCharID = nextUserID, nextCharID
+		m.audit = m.audit[:auditLen]
+	}
+}
+
+func (m *MemoryStore) countByUser(userID int) int {
+	count := 0
+	for _, character := range m.characters {
+		if character.UserID == userID && character.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
+}
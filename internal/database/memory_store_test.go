@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/models"
+)
+
+// testClock est une horloge avancée à la main.
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time          { return c.now }
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func setTestClasses(t *testing.T) {
+	t.Helper()
+	registry, err := models.NewClassRegistry([]models.ClassInfo{{
+		ID:             "warrior",
+		Name:           "Guerrier",
+		IconPath:       "res://icons/warrior.png",
+		BaseStats:      models.ClassStats{Vitality: 20, Strength: 15},
+		Growth:         models.ClassStats{Vitality: 2, Strength: 1},
+		StartingSpells: []string{"WAR_SLASH"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := models.Classes()
+	models.SetClassRegistry(registry)
+	t.Cleanup(func() { models.SetClassRegistry(previous) })
+}
+
+// newTestStore crée un MemoryStore avec un utilisateur et une horloge de test.
+func newTestStore(t *testing.T) (*MemoryStore, *testClock, int) {
+	t.Helper()
+	setTestClasses(t)
+
+	clock := &testClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.SetClock(clock.Now)
+	store.SetSpellTemplates(models.SpellTemplate{ID: "WAR_SLASH", Class: "warrior", MinLevel: 1})
+
+	user := &User{Username: "alice", Email: "alice@example.com"}
+	if err := store.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return store, clock, user.ID
+}
+
+func createCharacter(t *testing.T, store *MemoryStore, userID int, name string) *models.Character {
+	t.Helper()
+	character, err := store.CreateCharacter(context.Background(), userID, models.CreateCharacterRequest{Name: name, Class: "warrior"})
+	if err != nil {
+		t.Fatalf("création de %q: %v", name, err)
+	}
+	return character
+}
+
+func TestMemoryStoreCharacterNameIsCaseInsensitive(t *testing.T) {
+	store, _, userID := newTestStore(t)
+	ctx := context.Background()
+	createCharacter(t, store, userID, "Aragorn")
+
+	_, err := store.CreateCharacter(ctx, userID, models.CreateCharacterRequest{Name: "aRAGORN", Class: "warrior"})
+	if !errors.Is(err, ErrNameTaken) {
+		t.Fatalf("erreur = %v, attendu ErrNameTaken", err)
+	}
+
+	exists, err := store.CharacterNameExists(ctx, "ARAGORN")
+	if err != nil || !exists {
+		t.Fatalf("CharacterNameExists = %v, %v, attendu true", exists, err)
+	}
+}
+
+func TestMemoryStoreCharacterLimit(t *testing.T) {
+	store, _, userID := newTestStore(t)
+	ctx := context.Background()
+
+	var characters []*models.Character
+	for i := 0; i < MaxCharactersPerUser; i++ {
+		characters = append(characters, createCharacter(t, store, userID, fmt.Sprintf("Hero%d", i)))
+	}
+
+	_, err := store.CreateCharacter(ctx, userID, models.CreateCharacterRequest{Name: "OneTooMany", Class: "warrior"})
+	if !errors.Is(err, ErrCharacterLimit) {
+		t.Fatalf("erreur = %v, attendu ErrCharacterLimit", err)
+	}
+
+	// Un personnage supprimé ne compte plus dans la limite...
+	if err := store.DeleteCharacter(ctx, characters[0].ID, userID); err != nil {
+		t.Fatal(err)
+	}
+	createCharacter(t, store, userID, "Replacement")
+
+	// ...mais ne peut pas être restauré tant que la limite est atteinte
+	_, err = store.RestoreCharacter(ctx, characters[0].ID, userID)
+	if !errors.Is(err, ErrCharacterLimit) {
+		t.Fatalf("restauration: erreur = %v, attendu ErrCharacterLimit", err)
+	}
+}
+
+func TestMemoryStoreRestoreWithinGracePeriod(t *testing.T) {
+	store, clock, userID := newTestStore(t)
+	ctx := context.Background()
+	character := createCharacter(t, store, userID, "Legolas")
+
+	if err := store.DeleteCharacter(ctx, character.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetCharacterByID(ctx, character.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("personnage supprimé encore lisible: %v", err)
+	}
+
+	deleted, err := store.GetDeletedCharactersByUser(ctx, userID)
+	if err != nil || len(deleted) != 1 {
+		t.Fatalf("personnages supprimés = %v, %v, attendu 1", deleted, err)
+	}
+	if want := clock.now.Add(DefaultDeleteGracePeriod); deleted[0].PurgeAt == nil || !deleted[0].PurgeAt.Equal(want) {
+		t.Fatalf("PurgeAt = %v, attendu %v", deleted[0].PurgeAt, want)
+	}
+
+	clock.Advance(DefaultDeleteGracePeriod - time.Minute)
+	restored, err := store.RestoreCharacter(ctx, character.ID, userID)
+	if err != nil {
+		t.Fatalf("restauration pendant le délai de grâce: %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Fatal("le personnage restauré ne doit plus être marqué supprimé")
+	}
+}
+
+func TestMemoryStoreRestoreAfterGracePeriod(t *testing.T) {
+	store, clock, userID := newTestStore(t)
+	ctx := context.Background()
+	character := createCharacter(t, store, userID, "Gimli")
+
+	if err := store.DeleteCharacter(ctx, character.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(DefaultDeleteGracePeriod + time.Minute)
+	if _, err := store.RestoreCharacter(ctx, character.ID, userID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("restauration après le délai: erreur = %v, attendu ErrNotFound", err)
+	}
+
+	// Le nom reste réservé jusqu'à la purge
+	if exists, _ := store.CharacterNameExists(ctx, "gimli"); !exists {
+		t.Fatal("le nom doit rester réservé avant la purge")
+	}
+	purged, err := store.PurgeDeletedCharacters(ctx)
+	if err != nil || purged != 1 {
+		t.Fatalf("purge = %d, %v, attendu 1", purged, err)
+	}
+	if exists, _ := store.CharacterNameExists(ctx, "gimli"); exists {
+		t.Fatal("le nom doit être libéré par la purge")
+	}
+}
+
+func TestMemoryStoreDeleteUserCascades(t *testing.T) {
+	store, _, userID := newTestStore(t)
+	ctx := context.Background()
+	character := createCharacter(t, store, userID, "Boromir")
+
+	spells, err := store.GetCharacterSpells(ctx, character.ID)
+	if err != nil || len(spells) != 1 {
+		t.Fatalf("sorts de départ = %v, %v, attendu WAR_SLASH", spells, err)
+	}
+
+	if err := store.DeleteUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.GetCharacterByID(ctx, character.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("personnage après suppression du compte: erreur = %v, attendu ErrNotFound", err)
+	}
+	if spells, _ := store.GetCharacterSpells(ctx, character.ID); len(spells) != 0 {
+		t.Fatalf("sorts après suppression du compte: %v", spells)
+	}
+	if exists, _ := store.CharacterNameExists(ctx, "Boromir"); exists {
+		t.Fatal("le nom doit être libéré avec le compte")
+	}
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrMFANotFound est retourné quand l'utilisateur n'a pas commencé d'enrôlement 2FA.
+var ErrMFANotFound = errors.New("2FA non configurée")
+
+type UserMFA struct {
+	UserID       int        `db:"user_id"`
+	TOTPSecret   string     `db:"totp_secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// Enabled indique si l'enrôlement a été confirmé.
+func (m *UserMFA) Enabled() bool {
+	return m.EnabledAt != nil
+}
+
+// GetUserMFA retourne la configuration 2FA de l'utilisateur.
+func (db *PostgresDB) GetUserMFA(ctx context.Context, userID int) (*UserMFA, error) {
+	query := `
+		SELECT user_id, totp_secret, enabled_at, last_used_step, created_at
+		FROM user_mfa
+		WHERE user_id = $1`
+
+	mfa := &UserMFA{}
+	err := db.pool.QueryRow(ctx, query, userID).Scan(&mfa.UserID, &mfa.TOTPSecret, &mfa.EnabledAt, &mfa.LastUsedStep, &mfa.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMFANotFound
+		}
+		return nil, err
+	}
+	return mfa, nil
+}
+
+// SavePendingMFA enregistre un nouveau secret non encore confirmé.
+// Un enrôlement déjà actif n'est pas remplacé.
+func (db *PostgresDB) SavePendingMFA(ctx context.Context, userID int, secret string) error {
+	query := `
+		INSERT INTO user_mfa (user_id, totp_secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET totp_secret = EXCLUDED.totp_secret, last_used_step = 0, created_at = NOW()
+		WHERE user_mfa.enabled_at IS NULL`
+
+	_, err := db.pool.Exec(ctx, query, userID, secret)
+	return err
+}
+
+// EnableMFA confirme l'enrôlement et remplace les codes de secours.
+func (db *PostgresDB) EnableMFA(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE user_mfa SET enabled_at = NOW(), last_used_step = $2 WHERE user_id = $1`
+	if _, err := tx.Exec(ctx, query, userID, step); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DisableMFA supprime le secret et les codes de secours.
+func (db *PostgresDB) DisableMFA(ctx context.Context, userID int) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseTOTPStep enregistre le pas de temps d'un code accepté.
+// Retourne false si un code de ce pas (ou d'un pas ultérieur) a déjà été utilisé.
+func (db *PostgresDB) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	tag, err := db.pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ConsumeRecoveryCode marque un code de secours comme utilisé. Retourne false s'il est inconnu ou déjà utilisé.
+func (db *PostgresDB) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `
+		UPDATE user_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	tag, err := db.pool.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReplaceRecoveryCodes remplace tous les codes de secours de l'utilisateur.
+func (db *PostgresDB) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		query := `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.Exec(ctx, query, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
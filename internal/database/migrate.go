@@ -0,0 +1,529 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// migrationLockID identifie le verrou consultatif PostgreSQL pris pendant les migrations,
+// pour que deux instances du serveur ne migrent pas la base en même temps.
+const migrationLockID int64 = 0x666c756d656e // "flumen"
+
+// seedsDir est le sous-répertoire des données de référence.
+const seedsDir = "seeds"
+
+// migrationFilePattern : NNNNNN_nom.up.sql ou NNNNNN_nom.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	// ErrChecksumMismatch est retourné quand le fichier d'une migration déjà appliquée a été modifié.
+	ErrChecksumMismatch = errors.New("migration appliquée modifiée depuis son application")
+	// ErrUnknownMigration est retourné quand la base contient une migration absente des fichiers.
+	ErrUnknownMigration = errors.New("migration appliquée inconnue")
+	// ErrIrreversibleMigration est retourné pour annuler une migration sans fichier down.
+	ErrIrreversibleMigration = errors.New("migration sans fichier down")
+)
+
+// Migration est une migration SQL versionnée.
+type Migration struct {
+	Version  int64
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string // SHA-256 du fichier up
+}
+
+// Seed est un fichier de données de référence, rejoué quand son contenu change.
+type Seed struct {
+	Name     string
+	SQL      string
+	Checksum string
+}
+
+// MigrationStatus décrit l'état d'une migration dans la base.
+type MigrationStatus struct {
+	Version          int64
+	Name             string
+	Applied          bool
+	AppliedAt        *time.Time
+	ChecksumMismatch bool
+	Unknown          bool // Appliquée mais absente des fichiers
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applique les migrations et les données de référence embarquées.
+type Migrator struct {
+	db         *PostgresDB
+	migrations []Migration // Triées par version
+	seeds      []Seed      // Triés par nom
+	logger     zerolog.Logger
+}
+
+// NewMigrator charge les migrations de fsys (à la racine) et les données de référence (dans seeds/).
+// Chaque migration appliquée ou annulée et chaque donnée de référence rejouée est journalisée dans logger.
+func NewMigrator(db *PostgresDB, fsys fs.FS, logger zerolog.Logger) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	seeds, err := loadSeeds(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, seeds: seeds, logger: logger}, nil
+}
+
+// loadMigrations lit et valide les fichiers de migration.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("fichier de migration mal nommé %s (attendu NNNNNN_nom.up.sql ou .down.sql)", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("version invalide dans %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("version %06d utilisée par %s et %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.UpSQL = string(content)
+			m.Checksum = checksum(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migration %06d_%s sans fichier up", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// loadSeeds lit les fichiers de données de référence, s'il y en a.
+func loadSeeds(fsys fs.FS) ([]Seed, error) {
+	entries, err := fs.ReadDir(fsys, seedsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var seeds []Seed
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, path.Join(seedsDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, Seed{Name: entry.Name(), SQL: string(content), Checksum: checksum(content)})
+	}
+	sort.Slice(seeds, func(i, j int) bool { return seeds[i].Name < seeds[j].Name })
+	return seeds, nil
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Up applique toutes les migrations en attente.
+func (m *Migrator) Up(ctx context.Context) error {
+	var latest int64
+	if len(m.migrations) > 0 {
+		latest = m.migrations[len(m.migrations)-1].Version
+	}
+	return m.To(ctx, latest)
+}
+
+// Down annule les steps dernières migrations appliquées.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.verifiedApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if steps > len(versions) {
+			steps = len(versions)
+		}
+
+		for _, version := range versions[:steps] {
+			if err := m.rollback(ctx, conn, m.find(version)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// To amène la base à la version donnée : les migrations en attente jusqu'à version sont
+// appliquées, les migrations appliquées au-delà sont annulées.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("version %06d: %w", version, ErrUnknownMigration)
+	}
+
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.verifiedApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := &m.migrations[i]
+			if _, ok := applied[migration.Version]; ok && migration.Version > version {
+				if err := m.rollback(ctx, conn, migration); err != nil {
+					return err
+				}
+			}
+		}
+		for i := range m.migrations {
+			migration := &m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+				if err := m.apply(ctx, conn, migration); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status retourne l'état de chaque migration connue ou appliquée. Il ne fait que lire la base :
+// ni verrou consultatif, ni création ou reprise des tables de suivi. Tant que ces tables n'existent pas,
+// les migrations sont en attente, ou appliquées jusqu'à la version d'une table de golang-migrate.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.appliedReadOnly(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if a, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			if !a.appliedAt.IsZero() {
+				appliedAt := a.appliedAt
+				status.AppliedAt = &appliedAt
+				status.ChecksumMismatch = a.checksum != migration.Checksum
+			}
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, a := range applied {
+		appliedAt := a.appliedAt
+		statuses = append(statuses, MigrationStatus{Version: version, Name: a.name, Applied: true, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// appliedReadOnly lit les migrations appliquées sans modifier le schéma de suivi. Une table
+// schema_migrations de golang-migrate pas encore reprise marque appliquées les migrations jusqu'à sa
+// version, sans date ni somme de contrôle.
+func (m *Migrator) appliedReadOnly(ctx context.Context) (map[int64]appliedMigration, error) {
+	var exists, legacy bool
+	err := m.db.pool.QueryRow(ctx, `
+		SELECT
+			EXISTS (
+				SELECT 1 FROM information_schema.tables
+				WHERE table_schema = current_schema() AND table_name = 'schema_migrations'
+			),
+			EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = 'schema_migrations' AND column_name = 'dirty'
+			)`).Scan(&exists, &legacy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !exists:
+		return map[int64]appliedMigration{}, nil
+	case legacy:
+		var legacyVersion int64
+		err := m.db.pool.QueryRow(ctx, `SELECT version FROM schema_migrations LIMIT 1`).Scan(&legacyVersion)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return map[int64]appliedMigration{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		applied := make(map[int64]appliedMigration)
+		for _, migration := range m.migrations {
+			if migration.Version > legacyVersion {
+				break
+			}
+			applied[migration.Version] = appliedMigration{name: migration.Name}
+		}
+		return applied, nil
+	}
+	return m.applied(ctx, m.db.pool)
+}
+
+// Seed applique les données de référence nouvelles ou modifiées. Les fichiers doivent être rejouables.
+func (m *Migrator) Seed(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		checksums, err := m.appliedSeeds(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, seed := range m.seeds {
+			if checksums[seed.Name] == seed.Checksum {
+				continue
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, seed.SQL); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `
+					INSERT INTO schema_seeds (name, checksum) VALUES ($1, $2)
+					ON CONFLICT (name) DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = NOW()`,
+					seed.Name, seed.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("données de référence %s: %w", seed.Name, err)
+			}
+			m.logger.Info().Str("seed", seed.Name).Msg("Seed applied")
+		}
+		return nil
+	})
+}
+
+// withLock exécute fn sur une connexion dédiée qui détient le verrou consultatif des migrations.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.db.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if err := m.ensureMigrationTables(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureMigrationTables crée les tables de suivi. Une table schema_migrations de golang-migrate
+// (version, dirty) est reprise : les migrations jusqu'à sa version sont marquées appliquées.
+func (m *Migrator) ensureMigrationTables(ctx context.Context, conn *pgxpool.Conn) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		var legacy bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = 'schema_migrations' AND column_name = 'dirty'
+			)`).Scan(&legacy)
+		if err != nil {
+			return err
+		}
+
+		var legacyVersion int64 = -1
+		if legacy {
+			var dirty bool
+			err := tx.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&legacyVersion, &dirty)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			if dirty {
+				return fmt.Errorf("schema_migrations de golang-migrate marquée dirty (version %d), à corriger manuellement", legacyVersion)
+			}
+			if _, err := tx.Exec(ctx, `ALTER TABLE schema_migrations RENAME TO schema_migrations_legacy`); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version BIGINT PRIMARY KEY,
+				name VARCHAR(255) NOT NULL,
+				checksum CHAR(64) NOT NULL,
+				applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+			);
+			CREATE TABLE IF NOT EXISTS schema_seeds (
+				name VARCHAR(255) PRIMARY KEY,
+				checksum CHAR(64) NOT NULL,
+				applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+			)`)
+		if err != nil || legacyVersion < 0 {
+			return err
+		}
+
+		// Les fichiers ont pu changer depuis : la somme de contrôle de référence est celle des fichiers actuels
+		for _, migration := range m.migrations {
+			if migration.Version > legacyVersion {
+				break
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+				migration.Version, migration.Name, migration.Checksum)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// applied retourne les migrations enregistrées comme appliquées.
+func (m *Migrator) applied(ctx context.Context, conn querier) (map[int64]appliedMigration, error) {
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var version int64
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+// appliedSeeds retourne la somme de contrôle des données de référence déjà appliquées, par nom.
+func (m *Migrator) appliedSeeds(ctx context.Context, conn *pgxpool.Conn) (map[string]string, error) {
+	rows, err := conn.Query(ctx, `SELECT name, checksum FROM schema_seeds`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checksums := make(map[string]string)
+	for rows.Next() {
+		var name, sum string
+		if err := rows.Scan(&name, &sum); err != nil {
+			return nil, err
+		}
+		checksums[name] = sum
+	}
+	return checksums, rows.Err()
+}
+
+// verifiedApplied retourne les migrations appliquées après avoir vérifié qu'elles correspondent aux fichiers.
+func (m *Migrator) verifiedApplied(ctx context.Context, conn *pgxpool.Conn) (map[int64]appliedMigration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for version, a := range applied {
+		migration := m.find(version)
+		if migration == nil {
+			return nil, fmt.Errorf("%06d_%s: %w", version, a.name, ErrUnknownMigration)
+		}
+		if migration.Checksum != a.checksum {
+			return nil, fmt.Errorf("%06d_%s: %w", version, migration.Name, ErrChecksumMismatch)
+		}
+	}
+	return applied, nil
+}
+
+// apply exécute une migration et l'enregistre dans la même transaction.
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, migration *Migration) error {
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.UpSQL); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+			migration.Version, migration.Name, migration.Checksum)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %06d_%s: %w", migration.Version, migration.Name, err)
+	}
+	m.logger.Info().Int64("version", migration.Version).Str("name", migration.Name).Msg("Migration applied")
+	return nil
+}
+
+// rollback annule une migration et la retire du suivi dans la même transaction.
+func (m *Migrator) rollback(ctx context.Context, conn *pgxpool.Conn, migration *Migration) error {
+	if strings.TrimSpace(migration.DownSQL) == "" {
+		return fmt.Errorf("%06d_%s: %w", migration.Version, migration.Name, ErrIrreversibleMigration)
+	}
+
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.DownSQL); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("rollback %06d_%s: %w", migration.Version, migration.Name, err)
+	}
+	m.logger.Info().Int64("version", migration.Version).Str("name", migration.Name).Msg("Migration rolled back")
+	return nil
+}
+
+// find retourne la migration de cette version, ou nil.
+func (m *Migrator) find(version int64) *Migration {
+	i := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version >= version })
+	if i < len(m.migrations) && m.migrations[i].Version == version {
+		return &m.migrations[i]
+	}
+	return nil
+}
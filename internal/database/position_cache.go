@@ -0,0 +1,342 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/models"
+)
+
+// DefaultPositionFlushInterval est la période d'écriture des positions en attente.
+const DefaultPositionFlushInterval = 2 * time.Second
+
+// positionFlushTimeout borne l'écriture finale des positions à l'arrêt du cache.
+const positionFlushTimeout = 10 * time.Second
+
+// CharacterPosition est la position d'un personnage dans le monde.
+type CharacterPosition struct {
+	CharacterID int
+	MapX        int
+	MapY        int
+	PosX        int
+	PosY        int
+}
+
+// PositionStore est un CharacterStore capable d'écrire un lot de positions en une seule requête.
+type PositionStore interface {
+	CharacterStore
+	UpdateCharacterPositions(ctx context.Context, positions []CharacterPosition) error
+}
+
+// PositionCacheStats sont les métriques d'écriture du PositionCache.
+type PositionCacheStats struct {
+	Pending          int   // Positions en attente d'écriture
+	Flushes          int64 // Écritures réussies
+	FailedFlushes    int64 // Écritures échouées (positions conservées)
+	FlushedPositions int64 // Positions écrites
+	DroppedPositions int64 // Positions refusées par la base (contrainte, valeur hors limites) et abandonnées
+	LastFlushLatency time.Duration
+	MaxFlushLatency  time.Duration
+	AvgFlushLatency  time.Duration
+}
+
+// PositionCache diffère l'écriture des positions des personnages : UpdateCharacterPosition ne fait que
+// marquer la position en mémoire, et les positions modifiées sont écrites par lots par Run (à intervalle
+// régulier), FlushCharacter (déconnexion) et Close (arrêt). Les lectures de personnages renvoient la
+// position en attente. Les autres opérations sont déléguées au store.
+type PositionCache struct {
+	PositionStore
+
+	mu       sync.Mutex
+	dirty    map[int]CharacterPosition
+	inflight map[int]CharacterPosition // Lot en cours d'écriture, encore visible en lecture
+	active   map[int]struct{}          // Personnages dont l'existence a été vérifiée au premier déplacement
+	stats    PositionCacheStats
+	total    time.Duration // Somme des latences, pour la moyenne
+
+	flushMu   sync.Mutex // Une seule écriture à la fois : les lots ne se doublent pas
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+	startOnce sync.Once
+}
+
+// NewPositionCache crée un cache de positions devant store.
+func NewPositionCache(store PositionStore) *PositionCache {
+	return &PositionCache{
+		PositionStore: store,
+		dirty:         make(map[int]CharacterPosition),
+		active:        make(map[int]struct{}),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// UpdateCharacterPosition enregistre la position en mémoire ; elle sera écrite au prochain flush.
+// Au premier déplacement d'un personnage (ou au premier après sa déconnexion), son existence est
+// vérifiée dans le store : ErrNotFound est retourné s'il n'existe pas ou a été supprimé.
+func (c *PositionCache) UpdateCharacterPosition(ctx context.Context, characterID int, mapX, mapY, posX, posY int) error {
+	c.mu.Lock()
+	_, known := c.active[characterID]
+	c.mu.Unlock()
+
+	if !known {
+		if _, err := c.PositionStore.GetCharacterByID(ctx, characterID); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.active[characterID] = struct{}{}
+	c.dirty[characterID] = CharacterPosition{
+		CharacterID: characterID,
+		MapX:        mapX,
+		MapY:        mapY,
+		PosX:        posX,
+		PosY:        posY,
+	}
+	return nil
+}
+
+// GetCharacterByID récupère un personnage avec sa position en attente d'écriture
+func (c *PositionCache) GetCharacterByID(ctx context.Context, characterID int) (*models.Character, error) {
+	character, err := c.PositionStore.GetCharacterByID(ctx, characterID)
+	if err != nil {
+		return nil, err
+	}
+	c.applyPending(character)
+	return character, nil
+}
+
+// GetCharactersByUser récupère les personnages actifs d'un utilisateur avec leurs positions en attente d'écriture
+func (c *PositionCache) GetCharactersByUser(ctx context.Context, userID int) ([]models.Character, error) {
+	characters, err := c.PositionStore.GetCharactersByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range characters {
+		c.applyPending(&characters[i])
+	}
+	return characters, nil
+}
+
+// DeleteCharacter supprime un personnage après avoir écrit sa position, restaurée avec lui.
+func (c *PositionCache) DeleteCharacter(ctx context.Context, characterID, userID int) error {
+	if err := c.FlushCharacter(ctx, characterID); err != nil {
+		return err
+	}
+	if err := c.PositionStore.DeleteCharacter(ctx, characterID, userID); err != nil {
+		return err
+	}
+	c.forget(characterID)
+	return nil
+}
+
+// forget retire un personnage supprimé des personnages vérifiés, au cas où il aurait bougé pendant la suppression.
+func (c *PositionCache) forget(characterID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.active, characterID)
+}
+
+// Position retourne la position en attente d'écriture d'un personnage.
+func (c *PositionCache) Position(characterID int) (CharacterPosition, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pos, ok := c.dirty[characterID]; ok {
+		return pos, true
+	}
+	pos, ok := c.inflight[characterID]
+	return pos, ok
+}
+
+// Flush écrit toutes les positions en attente en une seule requête. En cas d'échec elles sont
+// conservées (sauf si une position plus récente a été enregistrée entre temps) pour le flush suivant ;
+// si la base refuse une des positions, le lot est réécrit ligne par ligne et seules les positions
+// refusées sont abandonnées.
+func (c *PositionCache) Flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	if len(c.dirty) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	batch := c.dirty
+	c.dirty = make(map[int]CharacterPosition, len(batch))
+	c.inflight = batch
+	c.mu.Unlock()
+
+	positions := make([]CharacterPosition, 0, len(batch))
+	for _, pos := range batch {
+		positions = append(positions, pos)
+	}
+	return c.write(ctx, positions)
+}
+
+// FlushCharacter écrit immédiatement la position en attente d'un personnage (déconnexion du joueur).
+// Son existence sera vérifiée de nouveau à son prochain déplacement.
+func (c *PositionCache) FlushCharacter(ctx context.Context, characterID int) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	delete(c.active, characterID)
+	pos, ok := c.dirty[characterID]
+	if ok {
+		delete(c.dirty, characterID)
+		c.inflight = map[int]CharacterPosition{characterID: pos}
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return c.write(ctx, []CharacterPosition{pos})
+}
+
+// Run écrit les positions en attente toutes les interval (DefaultPositionFlushInterval si nul),
+// jusqu'à l'annulation de ctx ou l'appel de Close. Les positions restantes sont écrites avant de rendre la main.
+func (c *PositionCache) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	started := false
+	c.startOnce.Do(func() { started = true })
+	if !started {
+		return
+	}
+	defer close(c.done)
+
+	if interval <= 0 {
+		interval = DefaultPositionFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		case <-ctx.Done():
+			c.finalFlush(onError)
+			return
+		case <-c.stop:
+			c.finalFlush(onError)
+			return
+		}
+	}
+}
+
+// Close arrête Run et écrit toutes les positions en attente. À appeler à l'arrêt du serveur.
+func (c *PositionCache) Close(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	// Run n'a peut-être jamais démarré : dans ce cas rien à attendre
+	c.startOnce.Do(func() { close(c.done) })
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return c.Flush(ctx)
+}
+
+// Stats retourne les métriques d'écriture.
+func (c *PositionCache) Stats() PositionCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Pending = len(c.dirty)
+	if stats.Flushes > 0 {
+		stats.AvgFlushLatency = c.total / time.Duration(stats.Flushes)
+	}
+	return stats
+}
+
+// write écrit un lot de positions et met à jour les métriques. Appelé avec flushMu verrouillé.
+func (c *PositionCache) write(ctx context.Context, positions []CharacterPosition) error {
+	start := time.Now()
+	err := c.PositionStore.UpdateCharacterPositions(ctx, positions)
+
+	// Une position refusée par la base ferait échouer chaque lot suivant : elle est isolée et abandonnée
+	var failed, dropped []CharacterPosition
+	switch {
+	case err == nil:
+	case isDataError(err) && len(positions) > 1:
+		failed, dropped, err = c.writeEach(ctx, positions)
+	case isDataError(err):
+		dropped = positions
+	default:
+		failed = positions
+	}
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inflight = nil
+	c.stats.DroppedPositions += int64(len(dropped))
+	if len(failed) > 0 {
+		c.stats.FailedFlushes++
+		for _, pos := range failed {
+			if _, newer := c.dirty[pos.CharacterID]; !newer {
+				c.dirty[pos.CharacterID] = pos
+			}
+		}
+		return fmt.Errorf("écriture de %d positions: %w", len(failed), err)
+	}
+
+	c.stats.Flushes++
+	c.stats.FlushedPositions += int64(len(positions) - len(dropped))
+	c.stats.LastFlushLatency = latency
+	if latency > c.stats.MaxFlushLatency {
+		c.stats.MaxFlushLatency = latency
+	}
+	c.total += latency
+	if len(dropped) > 0 {
+		return fmt.Errorf("%d positions refusées et abandonnées: %w", len(dropped), err)
+	}
+	return nil
+}
+
+// writeEach réécrit une à une les positions d'un lot refusé par la base. Les positions refusées sont
+// retournées dans dropped, celles qui échouent pour une autre raison (connexion) dans failed.
+func (c *PositionCache) writeEach(ctx context.Context, positions []CharacterPosition) (failed, dropped []CharacterPosition, err error) {
+	for _, pos := range positions {
+		rowErr := c.PositionStore.UpdateCharacterPositions(ctx, []CharacterPosition{pos})
+		switch {
+		case rowErr == nil:
+		case isDataError(rowErr):
+			dropped = append(dropped, pos)
+			err = rowErr
+		default:
+			failed = append(failed, pos)
+			err = rowErr
+		}
+	}
+	return failed, dropped, err
+}
+
+// finalFlush écrit les positions restantes avec un contexte indépendant de celui, déjà annulé, de Run.
+func (c *PositionCache) finalFlush(onError func(error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), positionFlushTimeout)
+	defer cancel()
+	if err := c.Flush(ctx); err != nil && onError != nil {
+		onError(err)
+	}
+}
+
+func (c *PositionCache) applyPending(character *models.Character) {
+	if pos, ok := c.Position(character.ID); ok {
+		character.MapX, character.MapY = pos.MapX, pos.MapY
+		character.PosX, character.PosY = pos.PosX, pos.PosY
+	}
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// checkedPositionStore refuse tout le lot quand une position viole les contraintes CHECK de characters,
+// comme l'UPDATE ... FROM unnest(...) de CharacterRepository.
+type checkedPositionStore struct {
+	*MemoryStore
+	batches int
+}
+
+func (s *checkedPositionStore) UpdateCharacterPositions(ctx context.Context, positions []CharacterPosition) error {
+	s.batches++
+	for _, pos := range positions {
+		if pos.PosX < 0 || pos.PosX >= 30 || pos.PosY < 0 || pos.PosY >= 30 {
+			return &pgconn.PgError{Code: checkViolation, ConstraintName: "characters_pos_x_check"}
+		}
+	}
+	return s.MemoryStore.UpdateCharacterPositions(ctx, positions)
+}
+
+func TestPositionCacheDropsRejectedPositions(t *testing.T) {
+	memory, _, userID := newTestStore(t)
+	ctx := context.Background()
+	good := createCharacter(t, memory, userID, "Frodo")
+	bad := createCharacter(t, memory, userID, "Sam")
+
+	store := &checkedPositionStore{MemoryStore: memory}
+	cache := NewPositionCache(store)
+	if err := cache.UpdateCharacterPosition(ctx, good.ID, 1, 2, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.UpdateCharacterPosition(ctx, bad.ID, 0, 0, 99, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cache.Flush(ctx)
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != checkViolation {
+		t.Fatalf("Flush = %v, attendu la violation de contrainte", err)
+	}
+
+	stored, err := memory.GetCharacterByID(ctx, good.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.MapX != 1 || stored.MapY != 2 || stored.PosX != 3 || stored.PosY != 4 {
+		t.Fatalf("position valide non écrite: %+v", stored)
+	}
+
+	stats := cache.Stats()
+	if stats.Pending != 0 || stats.DroppedPositions != 1 || stats.FlushedPositions != 1 {
+		t.Fatalf("stats = %+v, attendu 0 en attente, 1 abandonnée, 1 écrite", stats)
+	}
+
+	// La position refusée n'est pas remise en attente : le flush suivant n'a rien à écrire
+	batches := store.batches
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("flush suivant: %v", err)
+	}
+	if store.batches != batches {
+		t.Fatal("le flush suivant ne doit pas réécrire la position refusée")
+	}
+}
+
+func TestPositionCacheRejectsUnknownCharacters(t *testing.T) {
+	memory, _, userID := newTestStore(t)
+	ctx := context.Background()
+	character := createCharacter(t, memory, userID, "Merry")
+	cache := NewPositionCache(memory)
+
+	if err := cache.UpdateCharacterPosition(ctx, character.ID+100, 0, 0, 1, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("personnage inconnu: erreur = %v, attendu ErrNotFound", err)
+	}
+
+	if err := cache.UpdateCharacterPosition(ctx, character.ID, 0, 0, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.DeleteCharacter(ctx, character.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.UpdateCharacterPosition(ctx, character.ID, 0, 0, 2, 2); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("personnage supprimé: erreur = %v, attendu ErrNotFound", err)
+	}
+	if pending := cache.Stats().Pending; pending != 0 {
+		t.Fatalf("%d positions en attente, attendu 0", pending)
+	}
+}
@@ -2,17 +2,67 @@ package database
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Codes SQLSTATE des violations de contraintes.
+const (
+	uniqueViolation     = "23505"
+	checkViolation      = "23514"
+	foreignKeyViolation = "23503"
+)
+
+// Codes SQLSTATE des échecs de transaction à rejouer.
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// isDataError indique si l'erreur vient des données envoyées (classes SQLSTATE 22 et 23 : valeur hors
+// limites, violation de contrainte) plutôt que de la connexion ou du serveur.
+func isDataError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")
+	}
+	return false
+}
+
+// querier est l'interface commune à *pgxpool.Pool et pgx.Tx : les repositories s'exécutent
+// indifféremment sur le pool ou dans une transaction (Begin ouvre alors un savepoint).
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// queryTimeout borne la durée d'une requête, en plus de l'annulation du contexte de l'appelant.
+const queryTimeout = 5 * time.Second
+
+var (
+	// ErrNotFound est retourné quand l'enregistrement demandé n'existe pas.
+	ErrNotFound = errors.New("enregistrement introuvable")
+	// ErrUsernameTaken est retourné quand le username est déjà utilisé (insensible à la casse).
+	ErrUsernameTaken = errors.New("username déjà utilisé")
+	// ErrEmailTaken est retourné quand l'email est déjà utilisé (insensible à la casse).
+	ErrEmailTaken = errors.New("email déjà utilisé")
 )
 
 type User struct {
-	ID             string    `db:"id"`
-	Username       string    `db:"username"`
-	Email          string    `db:"email"`
-	PasswordHash   string    `db:"password_hash"`
-	CharacterClass string    `db:"character_class"`
-	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
+	ID              int        `db:"id"`
+	Username        string     `db:"username"`
+	Email           string     `db:"email"`
+	PasswordHash    string     `db:"password_hash"`
+	CharacterClass  string     `db:"character_class"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
 }
 
 func (db *PostgresDB) CreateUser(ctx context.Context, user *User) error {
@@ -21,15 +71,34 @@ func (db *PostgresDB) CreateUser(ctx context.Context, user *User) error {
 		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at, updated_at`
 
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
 	err := db.pool.QueryRow(ctx, query, user.Username, user.Email, user.PasswordHash, user.CharacterClass).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		switch {
+		case strings.Contains(pgErr.ConstraintName, "username"):
+			return ErrUsernameTaken
+		case strings.Contains(pgErr.ConstraintName, "email"):
+			return ErrEmailTaken
+		}
+	}
 	return err
 }
 
 func (db *PostgresDB) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	user := &User{}
-	query := "SELECT id, username, email, password_hash, character_class FROM users WHERE email = $1"
-	err := db.pool.QueryRow(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CharacterClass)
+	query := "SELECT id, username, email, password_hash, character_class, email_verified_at FROM users WHERE LOWER(email) = LOWER($1)"
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	err := db.pool.QueryRow(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CharacterClass, &user.EmailVerifiedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 	return user, nil
@@ -37,10 +106,48 @@ func (db *PostgresDB) GetUserByEmail(ctx context.Context, email string) (*User,
 
 func (db *PostgresDB) GetUserByUsername(ctx context.Context, username string) (*User, error) {
 	user := &User{}
-	query := "SELECT id, username, email, password_hash, character_class FROM users WHERE username = $1"
-	err := db.pool.QueryRow(ctx, query, username).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CharacterClass)
+	query := "SELECT id, username, email, password_hash, character_class, email_verified_at FROM users WHERE LOWER(username) = LOWER($1)"
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	err := db.pool.QueryRow(ctx, query, username).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CharacterClass, &user.EmailVerifiedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 	return user, nil
 }
+
+func (db *PostgresDB) GetUserByID(ctx context.Context, id int) (*User, error) {
+	user := &User{}
+	query := "SELECT id, username, email, password_hash, character_class, email_verified_at FROM users WHERE id = $1"
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	err := db.pool.QueryRow(ctx, query, id).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CharacterClass, &user.EmailVerifiedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser supprime un utilisateur ; ses personnages, sessions et tokens sont supprimés en cascade.
+func (db *PostgresDB) DeleteUser(ctx context.Context, id int) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	_, err := db.pool.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	return err
+}
+
+// queryContext dérive de ctx un contexte limité à queryTimeout.
+func queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, queryTimeout)
+}
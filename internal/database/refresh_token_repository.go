@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrRefreshTokenInvalid est retourné quand le refresh token est inconnu, expiré ou révoqué.
+	ErrRefreshTokenInvalid = errors.New("refresh token invalide")
+	// ErrRefreshTokenReused est retourné quand un refresh token déjà consommé est présenté à nouveau.
+	// Toute la session est alors révoquée.
+	ErrRefreshTokenReused = errors.New("réutilisation de refresh token détectée")
+)
+
+type RefreshToken struct {
+	ID        string     `db:"id"`
+	UserID    int        `db:"user_id"`
+	SessionID string     `db:"session_id"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// CreateRefreshToken enregistre un refresh token nouvellement émis.
+func (db *PostgresDB) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, session_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+
+	return db.pool.QueryRow(ctx, query, token.ID, token.UserID, token.SessionID, token.ExpiresAt).Scan(&token.CreatedAt)
+}
+
+// RotateRefreshToken consomme le refresh token oldID et enregistre next dans la même transaction.
+// Si oldID a déjà été consommé, la session entière est révoquée et ErrRefreshTokenReused est retourné.
+func (db *PostgresDB) RotateRefreshToken(ctx context.Context, oldID string, next *RefreshToken) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID int
+	var sessionID string
+	var usedAt, revokedAt *time.Time
+	var expiresAt time.Time
+	query := `
+		SELECT user_id, session_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE id = $1
+		FOR UPDATE`
+	err = tx.QueryRow(ctx, query, oldID).Scan(&userID, &sessionID, &expiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRefreshTokenInvalid
+		}
+		return err
+	}
+
+	if revokedAt != nil || !expiresAt.After(time.Now()) {
+		return ErrRefreshTokenInvalid
+	}
+
+	// Token déjà consommé : il a probablement été volé, on révoque toute la session
+	if usedAt != nil {
+		revokeQuery := `UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+		if _, err := tx.Exec(ctx, revokeQuery, sessionID); err != nil {
+			return err
+		}
+		if err := revokeSessionRefreshTokens(ctx, tx, sessionID); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+
+	if next.UserID != userID || next.SessionID != sessionID {
+		return ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`, oldID); err != nil {
+		return err
+	}
+
+	insertQuery := `
+		INSERT INTO refresh_tokens (id, user_id, session_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+	if err := tx.QueryRow(ctx, insertQuery, next.ID, next.UserID, next.SessionID, next.ExpiresAt).Scan(&next.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
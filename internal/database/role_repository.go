@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+)
+
+// GetUserRoles retourne les rôles attribués à l'utilisateur (hors rôle player implicite).
+func (db *PostgresDB) GetUserRoles(ctx context.Context, userID int) ([]string, error) {
+	rows, err := db.pool.Query(ctx, `SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GrantRole attribue un rôle à l'utilisateur. Attribuer un rôle déjà présent est sans effet.
+func (db *PostgresDB) GrantRole(ctx context.Context, userID int, role string, grantedBy int) error {
+	query := `
+		INSERT INTO user_roles (user_id, role, granted_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, role) DO NOTHING`
+
+	_, err := db.pool.Exec(ctx, query, userID, role, grantedBy)
+	return err
+}
+
+// RevokeRole retire un rôle à l'utilisateur. Retourne false si l'utilisateur n'avait pas ce rôle.
+func (db *PostgresDB) RevokeRole(ctx context.Context, userID int, role string) (bool, error) {
+	tag, err := db.pool.Exec(ctx, `DELETE FROM user_roles WHERE user_id = $1 AND role = $2`, userID, role)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Types de sanction.
+const (
+	SanctionBan  = "ban"  // Connexion refusée (suspension si temporaire)
+	SanctionMute = "mute" // Chat interdit
+)
+
+var (
+	ErrSanctionNotFound  = errors.New("sanction introuvable")
+	ErrCharacterNotOwned = errors.New("personnage non autorisé")
+)
+
+// Sanction est une mesure de modération sur un compte ou un seul de ses personnages.
+type Sanction struct {
+	ID          int64      `db:"id"`
+	UserID      int        `db:"user_id"`
+	CharacterID *int       `db:"character_id"` // nil : tout le compte
+	Type        string     `db:"type"`
+	Reason      string     `db:"reason"`
+	IssuedBy    *int       `db:"issued_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+	ExpiresAt   *time.Time `db:"expires_at"` // nil : permanente
+	RevokedAt   *time.Time `db:"revoked_at"`
+	RevokedBy   *int       `db:"revoked_by"`
+}
+
+// Active indique si la sanction s'applique à l'instant now.
+func (s *Sanction) Active(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || s.ExpiresAt.After(now))
+}
+
+const sanctionColumns = `id, user_id, character_id, type, reason, issued_by, created_at, expires_at, revoked_at, revoked_by`
+
+// CreateSanction enregistre une sanction. Un personnage ciblé doit appartenir à l'utilisateur.
+func (db *PostgresDB) CreateSanction(ctx context.Context, sanction *Sanction) error {
+	query := `
+		INSERT INTO sanctions (user_id, character_id, type, reason, issued_by, expires_at)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE $2::INTEGER IS NULL OR EXISTS (SELECT 1 FROM characters WHERE id = $2 AND user_id = $1)
+		RETURNING id, created_at`
+
+	err := db.pool.QueryRow(ctx, query,
+		sanction.UserID, sanction.CharacterID, sanction.Type, sanction.Reason, sanction.IssuedBy, sanction.ExpiresAt,
+	).Scan(&sanction.ID, &sanction.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCharacterNotOwned
+	}
+	return err
+}
+
+// GetActiveSanction retourne la sanction en cours la plus longue du type donné (le chat l'utilise pour les mutes).
+// Sans characterID seules les sanctions du compte sont prises en compte ; avec, celles du
+// compte et du personnage. Retourne ErrSanctionNotFound si aucune sanction ne s'applique.
+func (db *PostgresDB) GetActiveSanction(ctx context.Context, userID int, characterID *int, sanctionType string) (*Sanction, error) {
+	query := `
+		SELECT ` + sanctionColumns + `
+		FROM sanctions
+		WHERE user_id = $1 AND type = $3
+			AND (character_id IS NULL OR character_id = $2)
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at DESC NULLS FIRST
+		LIMIT 1`
+
+	sanction, err := scanSanction(db.pool.QueryRow(ctx, query, userID, characterID, sanctionType))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSanctionNotFound
+	}
+	return sanction, err
+}
+
+// GetUserSanctions retourne l'historique complet des sanctions d'un utilisateur, les plus récentes d'abord.
+func (db *PostgresDB) GetUserSanctions(ctx context.Context, userID int) ([]Sanction, error) {
+	query := `
+		SELECT ` + sanctionColumns + `
+		FROM sanctions
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := db.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sanctions := []Sanction{}
+	for rows.Next() {
+		sanction, err := scanSanction(rows)
+		if err != nil {
+			return nil, err
+		}
+		sanctions = append(sanctions, *sanction)
+	}
+	return sanctions, rows.Err()
+}
+
+// RevokeSanction lève une sanction en cours du type donné. La sanction reste dans l'historique.
+func (db *PostgresDB) RevokeSanction(ctx context.Context, sanctionID int64, sanctionType string, revokedBy int) (*Sanction, error) {
+	query := `
+		UPDATE sanctions
+		SET revoked_at = NOW(), revoked_by = $3
+		WHERE id = $1 AND type = $2 AND revoked_at IS NULL
+		RETURNING ` + sanctionColumns
+
+	sanction, err := scanSanction(db.pool.QueryRow(ctx, query, sanctionID, sanctionType, revokedBy))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSanctionNotFound
+	}
+	return sanction, err
+}
+
+// scanSanction lit une ligne sélectionnée avec sanctionColumns.
+func scanSanction(row pgx.Row) (*Sanction, error) {
+	var s Sanction
+	err := row.Scan(&s.ID, &s.UserID, &s.CharacterID, &s.Type, &s.Reason, &s.IssuedBy, &s.CreatedAt, &s.ExpiresAt, &s.RevokedAt, &s.RevokedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
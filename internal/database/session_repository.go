@@ -0,0 +1,221 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSessionNotFound est retourné quand la session n'existe pas ou n'appartient pas à l'utilisateur.
+var ErrSessionNotFound = errors.New("session introuvable")
+
+type Session struct {
+	ID         string     `db:"id"`
+	UserID     int        `db:"user_id"`
+	DeviceName string     `db:"device_name"`
+	IPAddress  string     `db:"ip_address"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastSeenAt time.Time  `db:"last_seen_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+// CreateSession enregistre une nouvelle session de connexion.
+func (db *PostgresDB) CreateSession(ctx context.Context, session *Session) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_sessions (id, user_id, device_name, ip_address)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, last_seen_at`
+
+	return db.pool.QueryRow(ctx, query, session.ID, session.UserID, session.DeviceName, session.IPAddress).Scan(&session.CreatedAt, &session.LastSeenAt)
+}
+
+// TouchSession met à jour la dernière activité et l'adresse IP d'une session active.
+func (db *PostgresDB) TouchSession(ctx context.Context, sessionID, ipAddress string) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE user_sessions
+		SET last_seen_at = NOW(), ip_address = $2
+		WHERE id = $1 AND revoked_at IS NULL`
+
+	_, err := db.pool.Exec(ctx, query, sessionID, ipAddress)
+	return err
+}
+
+// GetActiveSessions retourne les sessions non révoquées d'un utilisateur qui ont encore un refresh token valide,
+// les plus récemment actives d'abord.
+func (db *PostgresDB) GetActiveSessions(ctx context.Context, userID int) ([]Session, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT s.id, s.user_id, s.device_name, s.ip_address, s.created_at, s.last_seen_at
+		FROM user_sessions s
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL
+			AND EXISTS (
+				SELECT 1 FROM refresh_tokens rt
+				WHERE rt.session_id = s.id AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+			)
+		ORDER BY s.last_seen_at DESC`
+
+	rows, err := db.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.DeviceName, &session.IPAddress, &session.CreatedAt, &session.LastSeenAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession révoque une session et tous ses refresh tokens.
+func (db *PostgresDB) RevokeSession(ctx context.Context, sessionID string, userID int) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var revokedID string
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING id`
+	if err := tx.QueryRow(ctx, query, sessionID, userID).Scan(&revokedID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	if err := revokeSessionRefreshTokens(ctx, tx, sessionID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeAllUserSessions révoque toutes les sessions d'un utilisateur (déconnexion de tous les appareils, bannissement).
+func (db *PostgresDB) RevokeAllUserSessions(ctx context.Context, userID int) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return err
+	}
+
+	query = `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeToken ajoute un jti à la liste de révocation jusqu'à son expiration.
+func (db *PostgresDB) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO revoked_tokens (id, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO NOTHING`
+
+	_, err := db.pool.Exec(ctx, query, tokenID, userID, expiresAt)
+	return err
+}
+
+// IsTokenConsumed indique si un token à usage unique (par son jti) a déjà été utilisé.
+func (db *PostgresDB) IsTokenConsumed(ctx context.Context, tokenID string) (bool, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE id = $1)`
+
+	var consumed bool
+	if err := db.pool.QueryRow(ctx, query, tokenID).Scan(&consumed); err != nil {
+		return false, err
+	}
+	return consumed, nil
+}
+
+// ConsumeToken marque un token à usage unique (par son jti) comme utilisé jusqu'à son expiration.
+// Retourne false s'il l'était déjà : une seule requête concurrente peut le consommer.
+func (db *PostgresDB) ConsumeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) (bool, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO revoked_tokens (id, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO NOTHING`
+
+	tag, err := db.pool.Exec(ctx, query, tokenID, userID, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// IsTokenRevoked indique si le token (par son jti) ou sa session a été révoqué.
+func (db *PostgresDB) IsTokenRevoked(ctx context.Context, tokenID, sessionID string) (bool, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE id = $1)
+			OR NOT EXISTS(SELECT 1 FROM user_sessions WHERE id = $2 AND revoked_at IS NULL)`
+
+	var revoked bool
+	if err := db.pool.QueryRow(ctx, query, tokenID, sessionID).Scan(&revoked); err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+// PurgeExpiredTokens supprime les entrées de révocation et les refresh tokens expirés.
+func (db *PostgresDB) PurgeExpiredTokens(ctx context.Context) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	if _, err := db.pool.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	_, err := db.pool.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+	return err
+}
+
+// revokeSessionRefreshTokens révoque tous les refresh tokens d'une session.
+func revokeSessionRefreshTokens(ctx context.Context, tx pgx.Tx, sessionID string) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE session_id = $1 AND revoked_at IS NULL`
+	_, err := tx.Exec(ctx, query, sessionID)
+	return err
+}
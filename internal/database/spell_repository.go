@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/flumen/flumen_server/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrSpellNotFound est retourné pour un sort absent de spell_templates.
+	ErrSpellNotFound = errors.New("sort introuvable")
+	// ErrSpellNotLearnable est retourné quand la classe ou le niveau du personnage ne permet pas d'apprendre le sort.
+	ErrSpellNotLearnable = errors.New("sort non disponible pour ce personnage")
+	// ErrSpellAlreadyKnown est retourné quand le personnage connaît déjà le sort.
+	ErrSpellAlreadyKnown = errors.New("sort déjà appris")
+	// ErrSpellNotKnown est retourné quand le personnage ne connaît pas le sort.
+	ErrSpellNotKnown = errors.New("sort non appris")
+	// ErrSpellMaxLevel est retourné quand le sort est déjà au niveau models.MaxSpellLevel.
+	ErrSpellMaxLevel = errors.New("niveau maximum du sort atteint")
+)
+
+// characterSpellColumns sont les colonnes lues par scanCharacterSpell, sur character_spells (cs) et spell_templates (s).
+const characterSpellColumns = `s.id, COALESCE(s.class, ''), s.name, COALESCE(s.description, ''), s.min_level, s.pa_cost, s.range_min, s.range_max, COALESCE(s.area, ''), s.effects, cs.character_id, cs.level_learned, cs.spell_level, cs.xp, cs.learned_at`
+
+// SpellRepository gère les sorts appris par les personnages
+type SpellRepository struct {
+	q querier // Pool, ou transaction pour les repositories de WithTx
+}
+
+// NewSpellRepository crée un nouveau repository pour les sorts des personnages
+func NewSpellRepository(db *PostgresDB) *SpellRepository {
+	return &SpellRepository{q: db.pool}
+}
+
+// LearnSpell fait apprendre un sort au personnage, au niveau 1.
+// Retourne ErrNotFound si le personnage n'existe pas, ErrSpellNotFound si le sort n'existe pas,
+// ErrSpellNotLearnable si sa classe ou son niveau ne le permet pas et ErrSpellAlreadyKnown s'il le connaît déjà.
+func (r *SpellRepository) LearnSpell(ctx context.Context, characterID int, spellID string) (*models.CharacterSpell, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	// Vérifier la classe et le niveau requis
+	var class models.CharacterClass
+	var level int
+	query := `SELECT class, level FROM characters WHERE id = $1 AND deleted_at IS NULL`
+	if err := r.q.QueryRow(ctx, query, characterID).Scan(&class, &level); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("récupération du personnage: %w", err)
+	}
+
+	var template models.SpellTemplate
+	query = `SELECT COALESCE(class, ''), min_level FROM spell_templates WHERE id = $1`
+	if err := r.q.QueryRow(ctx, query, spellID).Scan(&template.Class, &template.MinLevel); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSpellNotFound
+		}
+		return nil, fmt.Errorf("récupération du sort: %w", err)
+	}
+	if !template.LearnableBy(class, level) {
+		return nil, ErrSpellNotLearnable
+	}
+
+	query = `
+		WITH cs AS (
+			INSERT INTO character_spells (character_id, spell_id, level_learned, spell_level, xp)
+			VALUES ($1, $2, $3, 1, 0)
+			RETURNING *
+		)
+		SELECT ` + characterSpellColumns + `
+		FROM cs
+		JOIN spell_templates s ON s.id = cs.spell_id
+	`
+	spell, err := scanCharacterSpell(r.q.QueryRow(ctx, query, characterID, spellID, level))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case uniqueViolation:
+				return nil, ErrSpellAlreadyKnown
+			case foreignKeyViolation:
+				return nil, ErrNotFound
+			}
+		}
+		return nil, fmt.Errorf("apprentissage du sort: %w", err)
+	}
+
+	return spell, nil
+}
+
+// LearnClassSpells fait apprendre au personnage tous les sorts de sa classe accessibles à son niveau
+// qu'il ne connaît pas encore (après une montée de niveau). Retourne le nombre de sorts appris.
+func (r *SpellRepository) LearnClassSpells(ctx context.Context, characterID int) (int, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	return learnClassSpells(ctx, r.q, characterID)
+}
+
+// GetCharacterSpells récupère les sorts appris par un personnage, par niveau requis
+func (r *SpellRepository) GetCharacterSpells(ctx context.Context, characterID int) ([]models.CharacterSpell, error) {
+	query := `
+		SELECT ` + characterSpellColumns + `
+		FROM character_spells cs
+		JOIN spell_templates s ON s.id = cs.spell_id
+		WHERE cs.character_id = $1
+		ORDER BY s.min_level, s.id
+	`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	rows, err := r.q.Query(ctx, query, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("récupération des sorts: %w", err)
+	}
+	defer rows.Close()
+
+	spells := []models.CharacterSpell{}
+	for rows.Next() {
+		spell, err := scanCharacterSpell(rows)
+		if err != nil {
+			return nil, fmt.Errorf("lecture du sort: %w", err)
+		}
+		spells = append(spells, *spell)
+	}
+
+	return spells, rows.Err()
+}
+
+// LevelUpSpell augmente d'un niveau un sort appris.
+// Retourne ErrSpellNotKnown si le personnage ne le connaît pas et ErrSpellMaxLevel s'il est au niveau maximum.
+func (r *SpellRepository) LevelUpSpell(ctx context.Context, characterID int, spellID string) (*models.CharacterSpell, error) {
+	query := `
+		WITH cs AS (
+			UPDATE character_spells
+			SET spell_level = spell_level + 1
+			WHERE character_id = $1 AND spell_id = $2 AND spell_level < $3
+			RETURNING *
+		)
+		SELECT ` + characterSpellColumns + `
+		FROM cs
+		JOIN spell_templates s ON s.id = cs.spell_id
+	`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	spell, err := scanCharacterSpell(r.q.QueryRow(ctx, query, characterID, spellID, models.MaxSpellLevel))
+	if err == nil {
+		return spell, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("montée de niveau du sort: %w", err)
+	}
+
+	// Aucune ligne : sort inconnu ou déjà au niveau maximum
+	var known bool
+	query = `SELECT EXISTS(SELECT 1 FROM character_spells WHERE character_id = $1 AND spell_id = $2)`
+	if err := r.q.QueryRow(ctx, query, characterID, spellID).Scan(&known); err != nil {
+		return nil, fmt.Errorf("montée de niveau du sort: %w", err)
+	}
+	if !known {
+		return nil, ErrSpellNotKnown
+	}
+	return nil, ErrSpellMaxLevel
+}
+
+// ForgetSpell retire un sort au personnage. Retourne ErrSpellNotKnown s'il ne le connaît pas.
+func (r *SpellRepository) ForgetSpell(ctx context.Context, characterID int, spellID string) error {
+	query := `DELETE FROM character_spells WHERE character_id = $1 AND spell_id = $2`
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	tag, err := r.q.Exec(ctx, query, characterID, spellID)
+	if err != nil {
+		return fmt.Errorf("oubli du sort: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSpellNotKnown
+	}
+
+	return nil
+}
+
+// learnClassSpells insère les sorts de la classe du personnage accessibles à son niveau.
+func learnClassSpells(ctx context.Context, q querier, characterID int) (int, error) {
+	query := `
+		INSERT INTO character_spells (character_id, spell_id, level_learned, spell_level, xp)
+		SELECT c.id, s.id, c.level, 1, 0
+		FROM characters c
+		JOIN spell_templates s ON (s.class IS NULL OR s.class = c.class) AND s.min_level <= c.level
+		WHERE c.id = $1 AND c.deleted_at IS NULL
+		ON CONFLICT (character_id, spell_id) DO NOTHING
+	`
+
+	tag, err := q.Exec(ctx, query, characterID)
+	if err != nil {
+		return 0, fmt.Errorf("apprentissage des sorts de classe: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// scanCharacterSpell lit une ligne sélectionnée avec characterSpellColumns.
+func scanCharacterSpell(row pgx.Row) (*models.CharacterSpell, error) {
+	var spell models.CharacterSpell
+	var effects []byte
+	err := row.Scan(
+		&spell.ID, &spell.Class, &spell.Name, &spell.Description, &spell.MinLevel,
+		&spell.PACost, &spell.RangeMin, &spell.RangeMax, &spell.Area, &effects,
+		&spell.CharacterID, &spell.LevelLearned, &spell.SpellLevel, &spell.XP, &spell.LearnedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	spell.Effects = effects
+	return &spell, nil
+}
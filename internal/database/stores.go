@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+
+	"github.com/flumen/flumen_server/internal/models"
+)
+
+// MaxCharactersPerUser est le nombre maximum de personnages par compte (limite Dofus).
+const MaxCharactersPerUser = 5
+
+// UserStore regroupe les opérations sur les comptes utilisateurs.
+// Un utilisateur introuvable est signalé par ErrNotFound.
+type UserStore interface {
+	CreateUser(ctx context.Context, user *User) error
+	GetUserByID(ctx context.Context, id int) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	DeleteUser(ctx context.Context, id int) error
+}
+
+// CharacterStore regroupe les opérations sur les personnages.
+// Les erreurs métier sont ErrNotFound, ErrNameTaken, ErrCharacterLimit, ErrInvalidClass et ErrVersionConflict.
+// La suppression est différée : un personnage supprimé n'est plus listé ni compté dans la limite,
+// mais son nom reste réservé et il peut être restauré jusqu'à sa purge.
+type CharacterStore interface {
+	CreateCharacter(ctx context.Context, userID int, req models.CreateCharacterRequest) (*models.Character, error)
+	GetCharactersByUser(ctx context.Context, userID int) ([]models.Character, error)
+	GetDeletedCharactersByUser(ctx context.Context, userID int) ([]models.Character, error)
+	GetCharacterByID(ctx context.Context, characterID int) (*models.Character, error)
+	UpdateCharacterPosition(ctx context.Context, characterID int, mapX, mapY, posX, posY int) error
+	UpdateCharacterLastLogin(ctx context.Context, characterID int) error
+	UpdateCharacterStats(ctx context.Context, character *models.Character) error
+	CharacterNameExists(ctx context.Context, name string) (bool, error)
+	GetCharacterCountByUser(ctx context.Context, userID int) (int, error)
+	DeleteCharacter(ctx context.Context, characterID, userID int) error
+	RestoreCharacter(ctx context.Context, characterID, userID int) (*models.Character, error)
+	PurgeDeletedCharacters(ctx context.Context) (int64, error)
+}
+
+// SpellStore regroupe les opérations sur les sorts appris par les personnages.
+// À sa création, un personnage n'apprend que les sorts de départ de sa classe (ClassInfo.StartingSpells) ;
+// LearnClassSpells lui fait ensuite apprendre ceux que son niveau débloque.
+// Les erreurs métier sont ErrNotFound (personnage), ErrSpellNotFound, ErrSpellNotLearnable,
+// ErrSpellAlreadyKnown, ErrSpellNotKnown et ErrSpellMaxLevel.
+type SpellStore interface {
+	LearnSpell(ctx context.Context, characterID int, spellID string) (*models.CharacterSpell, error)
+	LearnClassSpells(ctx context.Context, characterID int) (int, error)
+	GetCharacterSpells(ctx context.Context, characterID int) ([]models.CharacterSpell, error)
+	LevelUpSpell(ctx context.Context, characterID int, spellID string) (*models.CharacterSpell, error)
+	ForgetSpell(ctx context.Context, characterID int, spellID string) error
+}
+
+// AuditWriter ajoute des entrées au journal d'audit.
+type AuditWriter interface {
+	AppendAuditEntry(ctx context.Context, entry *AuditEntry) error
+}
+
+var (
+	_ AuditWriter   = (*PostgresDB)(nil)
+	_ UserStore     = (*PostgresDB)(nil)
+	_ PositionStore = (*CharacterRepository)(nil)
+	_ SpellStore    = (*SpellRepository)(nil)
+	_ UserStore     = (*MemoryStore)(nil)
+	_ PositionStore = (*MemoryStore)(nil)
+	_ PositionStore = (*PositionCache)(nil)
+	_ Transactor    = (*TxManager)(nil)
+	_ Transactor    = (*MemoryStore)(nil)
+	_ Stores        = (*MemoryStore)(nil)
+	_ AuditWriter   = (*MemoryStore)(nil)
+	_ SpellStore    = (*MemoryStore)(nil)
+)
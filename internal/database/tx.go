@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// maxTxAttempts est le nombre d'exécutions d'une transaction rejetée pour conflit de sérialisation.
+const maxTxAttempts = 5
+
+// txRetryDelay est l'attente de base avant de rejouer une transaction, doublée à chaque échec.
+const txRetryDelay = 10 * time.Millisecond
+
+// Stores regroupe les repositories liés à une même transaction. Toutes leurs écritures sont
+// validées ensemble à la fin de WithTx, ou annulées si la fonction retourne une erreur.
+type Stores interface {
+	Characters() CharacterStore
+	Spells() SpellStore
+	Audit() AuditWriter
+	// Savepoint exécute fn dans un savepoint : une erreur de fn n'annule que ses propres écritures,
+	// la transaction englobante peut continuer.
+	Savepoint(ctx context.Context, fn func(tx Stores) error) error
+}
+
+// Transactor exécute des opérations multi-tables (échanges, butin, apprentissage de sorts) de
+// façon atomique.
+type Transactor interface {
+	// WithTx exécute fn dans une transaction validée si fn ne retourne pas d'erreur. fn peut être
+	// rejouée après un conflit de sérialisation : elle ne doit pas avoir d'effet hors des Stores reçus.
+	WithTx(ctx context.Context, fn func(tx Stores) error) error
+}
+
+// TxManager est le Transactor PostgreSQL. Les transactions sont en isolation SERIALIZABLE et
+// rejouées jusqu'à maxTxAttempts fois en cas de conflit de sérialisation, d'interblocage ou de version.
+// Les repositories remis ne passent pas par le PositionCache : les positions en attente n'y sont
+// pas visibles.
+type TxManager struct {
+	db         *PostgresDB
+	characters *CharacterRepository
+}
+
+// NewTxManager crée un TxManager. Les repositories de personnages des transactions reprennent
+// la configuration de characters (délai de grâce des suppressions).
+func NewTxManager(db *PostgresDB, characters *CharacterRepository) *TxManager {
+	return &TxManager{db: db, characters: characters}
+}
+
+// WithTx exécute fn dans une transaction SERIALIZABLE, rejouée sur conflit de sérialisation.
+func (m *TxManager) WithTx(ctx context.Context, fn func(tx Stores) error) error {
+	delay := txRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := m.runTx(ctx, fn)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+		if attempt == maxTxAttempts {
+			return fmt.Errorf("transaction abandonnée après %d tentatives: %w", attempt, err)
+		}
+
+		// Attente aléatoire pour désynchroniser les transactions concurrentes
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+func (m *TxManager) runTx(ctx context.Context, fn func(tx Stores) error) error {
+	tx, err := m.db.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return fmt.Errorf("ouverture de la transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(m.stores(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("validation de la transaction: %w", err)
+	}
+	return nil
+}
+
+func (m *TxManager) stores(tx pgx.Tx) *txStores {
+	return &txStores{
+		tx:         tx,
+		characters: m.characters.withTx(tx),
+		spells:     &SpellRepository{q: tx},
+		audit:      auditRepository{q: tx},
+		manager:    m,
+	}
+}
+
+// txStores est l'implémentation de Stores liée à une transaction (ou à un savepoint) PostgreSQL.
+type txStores struct {
+	tx         pgx.Tx
+	characters *CharacterRepository
+	spells     *SpellRepository
+	audit      auditRepository
+	manager    *TxManager
+}
+
+func (s *txStores) Characters() CharacterStore { return s.characters }
+func (s *txStores) Spells() SpellStore         { return s.spells }
+func (s *txStores) Audit() AuditWriter         { return s.audit }
+
+// Savepoint exécute fn dans un savepoint de la transaction.
+func (s *txStores) Savepoint(ctx context.Context, fn func(tx Stores) error) error {
+	sp, err := s.tx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ouverture du savepoint: %w", err)
+	}
+	defer sp.Rollback(ctx)
+
+	if err := fn(s.manager.stores(sp)); err != nil {
+		return err
+	}
+
+	if err := sp.Commit(ctx); err != nil {
+		return fmt.Errorf("libération du savepoint: %w", err)
+	}
+	return nil
+}
+
+// isRetryableTxError indique si la transaction a échoué pour un conflit et peut être rejouée.
+// ErrVersionConflict en fait partie : les Stores ne rejouent pas eux-mêmes (ModifyCharacterOnce).
+func isRetryableTxError(err error) bool {
+	if errors.Is(err, ErrVersionConflict) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Usages des tokens envoyés par email.
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+// ErrUserTokenInvalid est retourné quand le token est inconnu, expiré ou déjà utilisé.
+var ErrUserTokenInvalid = errors.New("token invalide ou expiré")
+
+type UserToken struct {
+	ID        int64      `db:"id"`
+	UserID    int        `db:"user_id"`
+	Purpose   string     `db:"purpose"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// CreateUserToken enregistre un nouveau token et invalide les précédents du même usage,
+// pour qu'un seul lien soit valide à la fois.
+func (db *PostgresDB) CreateUserToken(ctx context.Context, token *UserToken) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+	if _, err := tx.Exec(ctx, query, token.UserID, token.Purpose); err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	if err := tx.QueryRow(ctx, query, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ConsumeUserToken marque le token comme utilisé et retourne l'utilisateur associé.
+// La consommation est atomique : un même token ne peut servir qu'une fois.
+func (db *PostgresDB) ConsumeUserToken(ctx context.Context, purpose, tokenHash string) (*UserToken, error) {
+	query := `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+
+	token := &UserToken{}
+	err := db.pool.QueryRow(ctx, query, tokenHash, purpose).Scan(
+		&token.ID, &token.UserID, &token.Purpose, &token.TokenHash,
+		&token.ExpiresAt, &token.UsedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserTokenInvalid
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+// MarkEmailVerified enregistre la vérification de l'email de l'utilisateur.
+func (db *PostgresDB) MarkEmailVerified(ctx context.Context, userID int) error {
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1`
+	_, err := db.pool.Exec(ctx, query, userID)
+	return err
+}
+
+// UpdatePassword remplace le hash du mot de passe de l'utilisateur.
+func (db *PostgresDB) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.pool.Exec(ctx, query, passwordHash, userID)
+	return err
+}
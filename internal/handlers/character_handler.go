@@ -1,44 +1,104 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 
 	"github.com/flumen/flumen_server/internal/auth"
 	"github.com/flumen/flumen_server/internal/database"
 	"github.com/flumen/flumen_server/internal/models"
 	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
 )
 
+// SanctionChecker recherche les sanctions en cours d'un compte ou d'un personnage.
+type SanctionChecker interface {
+	GetActiveSanction(ctx context.Context, userID int, characterID *int, sanctionType string) (*database.Sanction, error)
+}
+
 // CharacterHandler gère les requêtes liées aux personnages
 type CharacterHandler struct {
-	characterRepo *database.CharacterRepository
-	jwtService    *auth.JWTService
+	characterRepo database.CharacterStore
+	spells        database.SpellStore
+	sanctions     SanctionChecker
+	audit         database.AuditWriter
+	positions     *database.PositionCache
+	transactor    database.Transactor
+	logger        zerolog.Logger
+
+	mu       sync.Mutex
+	selected map[int]int // Personnage sélectionné par utilisateur (déplacements, déconnexion)
 }
 
-// NewCharacterHandler crée un nouveau handler pour les personnages
-func NewCharacterHandler(characterRepo *database.CharacterRepository, jwtService *auth.JWTService) *CharacterHandler {
+// NewCharacterHandler crée un nouveau handler pour les personnages.
+// characterRepo peut être un *database.CharacterRepository ou un *database.MemoryStore dans les tests.
+func NewCharacterHandler(characterRepo database.CharacterStore) *CharacterHandler {
 	return &CharacterHandler{
 		characterRepo: characterRepo,
-		jwtService:    jwtService,
+		logger:        zerolog.Nop(),
+		selected:      make(map[int]int),
 	}
 }
 
-// GetCharacters récupère tous les personnages d'un utilisateur
+// SetLogger définit le logger des erreurs qui n'interrompent pas les requêtes (audit, dernière connexion).
+func (h *CharacterHandler) SetLogger(logger zerolog.Logger) {
+	h.logger = logger
+}
+
+// SetSanctionChecker active le refus de sélection des personnages bannis.
+func (h *CharacterHandler) SetSanctionChecker(sanctions SanctionChecker) {
+	h.sanctions = sanctions
+}
+
+// SetAuditWriter active l'enregistrement des créations, sélections, suppressions, restaurations
+// et changements de map dans le journal d'audit.
+func (h *CharacterHandler) SetAuditWriter(audit database.AuditWriter) {
+	h.audit = audit
+}
+
+// SetPositionCache fait passer les lectures et écritures de personnages par le cache de positions,
+// qui diffère l'écriture des déplacements.
+func (h *CharacterHandler) SetPositionCache(positions *database.PositionCache) {
+	h.characterRepo = positions
+	h.positions = positions
+}
+
+// SetTransactor fait appliquer un gain d'expérience et l'apprentissage des sorts de classe débloqués
+// par les niveaux gagnés dans une même transaction.
+func (h *CharacterHandler) SetTransactor(transactor database.Transactor) {
+	h.transactor = transactor
+}
+
+// RegisterRoutes enregistre les routes REST des personnages, et celles de leurs sorts si SetSpellStore
+// a été appelé avant. Le router doit être protégé par auth.JWTService.Middleware.
+func (h *CharacterHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/characters", h.GetCharacters)
+	router.Post("/characters", h.CreateCharacter)
+	router.Post("/characters/:id/select", h.SelectCharacter)
+	router.Delete("/characters/:id", h.DeleteCharacter)
+	router.Post("/characters/:id/restore", h.RestoreCharacter)
+	router.Get("/classes", h.GetClassInfo)
+	if h.spells != nil {
+		h.registerSpellRoutes(router)
+	}
+}
+
+// GetCharacters récupère les personnages d'un utilisateur, ainsi que ses personnages supprimés encore restaurables
 func (h *CharacterHandler) GetCharacters(c *fiber.Ctx) error {
-	// Extraire l'utilisateur du token JWT
-	userID, err := h.getUserIDFromToken(c)
-	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"error":   "Token invalide",
-		})
+	// Utilisateur authentifié par le middleware JWT
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		return auth.Unauthorized(c, "", "Token manquant")
 	}
+	userID := principal.UserID
 
 	// Récupérer les personnages
-	characters, err := h.characterRepo.GetCharactersByUser(userID)
+	characters, deleted, err := h.listCharacters(c.Context(), userID)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -47,22 +107,21 @@ func (h *CharacterHandler) GetCharacters(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"success":    true,
-		"characters": characters,
-		"classes":    models.GetAllClasses(), // Envoyer aussi les infos des classes
+		"success":            true,
+		"characters":         characters,
+		"deleted_characters": deleted,
+		"classes":            models.GetAllClasses(), // Envoyer aussi les infos des classes
 	})
 }
 
 // CreateCharacter crée un nouveau personnage
 func (h *CharacterHandler) CreateCharacter(c *fiber.Ctx) error {
-	// Extraire l'utilisateur du token JWT
-	userID, err := h.getUserIDFromToken(c)
-	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"error":   "Token invalide",
-		})
+	// Utilisateur authentifié par le middleware JWT
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		return auth.Unauthorized(c, "", "Token manquant")
 	}
+	userID := principal.UserID
 
 	// Parser la requête
 	var req models.CreateCharacterRequest
@@ -82,13 +141,15 @@ func (h *CharacterHandler) CreateCharacter(c *fiber.Ctx) error {
 	}
 
 	// Créer le personnage
-	character, err := h.characterRepo.CreateCharacter(userID, req)
+	character, err := h.characterRepo.CreateCharacter(c.Context(), userID, req)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+		status, message := characterError(err, "Erreur lors de la création du personnage")
+		return c.Status(status).JSON(fiber.Map{
 			"success": false,
-			"error":   err.Error(),
+			"error":   message,
 		})
 	}
+	h.recordAudit(c.Context(), database.AuditCharacterCreate, userID, character.ID, c.IP(), nil, character)
 
 	return c.JSON(fiber.Map{
 		"success":   true,
@@ -98,14 +159,12 @@ func (h *CharacterHandler) CreateCharacter(c *fiber.Ctx) error {
 
 // SelectCharacter sélectionne un personnage pour jouer
 func (h *CharacterHandler) SelectCharacter(c *fiber.Ctx) error {
-	// Extraire l'utilisateur du token JWT
-	userID, err := h.getUserIDFromToken(c)
-	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"error":   "Token invalide",
-		})
+	// Utilisateur authentifié par le middleware JWT
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		return auth.Unauthorized(c, "", "Token manquant")
 	}
+	userID := principal.UserID
 
 	// Récupérer l'ID du personnage
 	characterIDStr := c.Params("id")
@@ -118,11 +177,12 @@ func (h *CharacterHandler) SelectCharacter(c *fiber.Ctx) error {
 	}
 
 	// Récupérer le personnage
-	character, err := h.characterRepo.GetCharacterByID(characterID)
+	character, err := h.characterRepo.GetCharacterByID(c.Context(), characterID)
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+		status, message := characterError(err, "Erreur lors de la récupération du personnage")
+		return c.Status(status).JSON(fiber.Map{
 			"success": false,
-			"error":   "Personnage non trouvé",
+			"error":   message,
 		})
 	}
 
@@ -134,12 +194,29 @@ func (h *CharacterHandler) SelectCharacter(c *fiber.Ctx) error {
 		})
 	}
 
+	// Vérifier que le personnage n'est pas banni
+	banned, err := h.isCharacterBanned(c.Context(), userID, characterID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erreur lors de la vérification des sanctions",
+		})
+	}
+	if banned {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "Personnage banni",
+		})
+	}
+
 	// Mettre à jour la dernière connexion
-	err = h.characterRepo.UpdateCharacterLastLogin(characterID)
+	err = h.characterRepo.UpdateCharacterLastLogin(c.Context(), characterID)
 	if err != nil {
 		// Log l'erreur mais ne pas faire échouer la requête
-		fmt.Printf("Erreur lors de la mise à jour de la dernière connexion: %v\n", err)
+		h.logger.Error().Err(err).Int("character_id", characterID).Msg("Failed to update character last login")
 	}
+	h.selectCharacter(userID, characterID)
+	h.recordAudit(c.Context(), database.AuditCharacterSelect, userID, characterID, c.IP(), nil, nil)
 
 	return c.JSON(fiber.Map{
 		"success":   true,
@@ -147,16 +224,14 @@ func (h *CharacterHandler) SelectCharacter(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteCharacter supprime un personnage
+// DeleteCharacter supprime un personnage. Il reste restaurable pendant le délai de grâce.
 func (h *CharacterHandler) DeleteCharacter(c *fiber.Ctx) error {
-	// Extraire l'utilisateur du token JWT
-	userID, err := h.getUserIDFromToken(c)
-	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"error":   "Token invalide",
-		})
+	// Utilisateur authentifié par le middleware JWT
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		return auth.Unauthorized(c, "", "Token manquant")
 	}
+	userID := principal.UserID
 
 	// Récupérer l'ID du personnage
 	characterIDStr := c.Params("id")
@@ -168,51 +243,76 @@ func (h *CharacterHandler) DeleteCharacter(c *fiber.Ctx) error {
 		})
 	}
 
+	// État avant suppression pour le journal d'audit
+	before := h.auditSnapshot(c.Context(), characterID)
+
 	// Supprimer le personnage
-	err = h.characterRepo.DeleteCharacter(characterID, userID)
+	err = h.characterRepo.DeleteCharacter(c.Context(), characterID, userID)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+		if errors.Is(err, database.ErrNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Personnage non trouvé ou non autorisé",
+			})
+		}
+		status, message := characterError(err, "Erreur lors de la suppression du personnage")
+		return c.Status(status).JSON(fiber.Map{
 			"success": false,
-			"error":   err.Error(),
+			"error":   message,
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "Personnage supprimé avec succès",
-	})
-}
+	h.deselectCharacter(userID, characterID)
+	h.recordAudit(c.Context(), database.AuditCharacterDelete, userID, characterID, c.IP(), before, nil)
 
-// GetClassInfo retourne les informations sur toutes les classes
-func (h *CharacterHandler) GetClassInfo(c *fiber.Ctx) error {
-	classes := models.GetAllClasses()
 	return c.JSON(fiber.Map{
 		"success": true,
-		"classes": classes,
+		"message": "Personnage supprimé avec succès",
 	})
 }
 
-// getUserIDFromToken extrait l'ID utilisateur du token JWT
-func (h *CharacterHandler) getUserIDFromToken(c *fiber.Ctx) (int, error) {
-	// Récupérer le token depuis l'en-tête Authorization
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return 0, fmt.Errorf("token manquant")
+// RestoreCharacter restaure un personnage supprimé pendant le délai de grâce
+func (h *CharacterHandler) RestoreCharacter(c *fiber.Ctx) error {
+	// Utilisateur authentifié par le middleware JWT
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		return auth.Unauthorized(c, "", "Token manquant")
 	}
+	userID := principal.UserID
 
-	// Extraire le token (format: "Bearer <token>")
-	token := authHeader
-	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		token = authHeader[7:]
+	// Récupérer l'ID du personnage
+	characterID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "ID de personnage invalide",
+		})
 	}
 
-	// Valider le token
-	claims, err := h.jwtService.ValidateToken(token)
+	// Restaurer le personnage
+	character, err := h.characterRepo.RestoreCharacter(c.Context(), characterID, userID)
 	if err != nil {
-		return 0, fmt.Errorf("token invalide: %w", err)
+		status, message := restoreError(err)
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   message,
+		})
 	}
+	h.recordAudit(c.Context(), database.AuditCharacterRestore, userID, characterID, c.IP(), nil, character)
 
-	return claims.UserID, nil
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"character": character,
+	})
+}
+
+// GetClassInfo retourne les informations sur toutes les classes
+func (h *CharacterHandler) GetClassInfo(c *fiber.Ctx) error {
+	classes := models.GetAllClasses()
+	return c.JSON(fiber.Map{
+		"success": true,
+		"classes": classes,
+	})
 }
 
 // validateCreateCharacterRequest valide les données de création de personnage
@@ -223,7 +323,7 @@ func (h *CharacterHandler) validateCreateCharacterRequest(req *models.CreateChar
 	}
 
 	// Vérifier la classe
-	if req.Class != models.ClassWarrior && req.Class != models.ClassArcher {
+	if _, ok := models.Classes().Get(req.Class); !ok {
 		return fmt.Errorf("classe invalide")
 	}
 
@@ -238,26 +338,39 @@ type CharacterWebSocketMessage struct {
 
 // HandleCharacterWebSocket gère les messages WebSocket liés aux personnages
 func (h *CharacterHandler) HandleCharacterWebSocket(message []byte, userID int) ([]byte, error) {
+	return h.HandleCharacterWebSocketFrom(message, userID, "")
+}
+
+// HandleCharacterWebSocketFrom gère les messages WebSocket liés aux personnages d'un client dont
+// l'adresse IP est enregistrée dans le journal d'audit.
+func (h *CharacterHandler) HandleCharacterWebSocketFrom(message []byte, userID int, ip string) ([]byte, error) {
 	var wsMsg CharacterWebSocketMessage
 	if err := json.Unmarshal(message, &wsMsg); err != nil {
 		return nil, fmt.Errorf("message invalide")
 	}
 
+	ctx := context.Background()
 	switch wsMsg.Type {
 	case "get_characters":
-		return h.handleGetCharactersWS(userID)
+		return h.handleGetCharactersWS(ctx, userID)
 	case "select_character":
-		return h.handleSelectCharacterWS(wsMsg.Data, userID)
+		return h.handleSelectCharacterWS(ctx, wsMsg.Data, userID, ip)
 	case "create_character":
-		return h.handleCreateCharacterWS(wsMsg.Data, userID)
+		return h.handleCreateCharacterWS(ctx, wsMsg.Data, userID, ip)
+	case "restore_character":
+		return h.handleRestoreCharacterWS(ctx, wsMsg.Data, userID, ip)
+	case "player_move":
+		return h.handlePlayerMoveWS(ctx, wsMsg.Data, userID)
+	case "change_map":
+		return h.handleChangeMapWS(ctx, wsMsg.Data, userID, ip)
 	default:
 		return nil, fmt.Errorf("type de message non supporté: %s", wsMsg.Type)
 	}
 }
 
 // handleGetCharactersWS gère la récupération des personnages via WebSocket
-func (h *CharacterHandler) handleGetCharactersWS(userID int) ([]byte, error) {
-	characters, err := h.characterRepo.GetCharactersByUser(userID)
+func (h *CharacterHandler) handleGetCharactersWS(ctx context.Context, userID int) ([]byte, error) {
+	characters, deleted, err := h.listCharacters(ctx, userID)
 	if err != nil {
 		return h.createErrorResponse("Erreur lors de la récupération des personnages")
 	}
@@ -265,9 +378,10 @@ func (h *CharacterHandler) handleGetCharactersWS(userID int) ([]byte, error) {
 	response := map[string]interface{}{
 		"type": "characters_list",
 		"data": map[string]interface{}{
-			"success":    true,
-			"characters": characters,
-			"classes":    models.GetAllClasses(),
+			"success":            true,
+			"characters":         characters,
+			"deleted_characters": deleted,
+			"classes":            models.GetAllClasses(),
 		},
 	}
 
@@ -275,7 +389,7 @@ func (h *CharacterHandler) handleGetCharactersWS(userID int) ([]byte, error) {
 }
 
 // handleSelectCharacterWS gère la sélection d'un personnage via WebSocket
-func (h *CharacterHandler) handleSelectCharacterWS(data json.RawMessage, userID int) ([]byte, error) {
+func (h *CharacterHandler) handleSelectCharacterWS(ctx context.Context, data json.RawMessage, userID int, ip string) ([]byte, error) {
 	var req struct {
 		CharacterID int `json:"character_id"`
 	}
@@ -284,17 +398,30 @@ func (h *CharacterHandler) handleSelectCharacterWS(data json.RawMessage, userID
 		return h.createErrorResponse("Données invalides")
 	}
 
-	character, err := h.characterRepo.GetCharacterByID(req.CharacterID)
+	character, err := h.characterRepo.GetCharacterByID(ctx, req.CharacterID)
 	if err != nil {
-		return h.createErrorResponse("Personnage non trouvé")
+		_, message := characterError(err, "Erreur lors de la récupération du personnage")
+		return h.createErrorResponse(message)
 	}
 
 	if character.UserID != userID {
 		return h.createErrorResponse("Personnage non autorisé")
 	}
 
+	banned, err := h.isCharacterBanned(ctx, userID, req.CharacterID)
+	if err != nil {
+		return h.createErrorResponse("Erreur lors de la vérification des sanctions")
+	}
+	if banned {
+		return h.createErrorResponse("Personnage banni")
+	}
+
 	// Mettre à jour la dernière connexion
-	h.characterRepo.UpdateCharacterLastLogin(req.CharacterID)
+	if err := h.characterRepo.UpdateCharacterLastLogin(ctx, req.CharacterID); err != nil {
+		h.logger.Error().Err(err).Int("character_id", req.CharacterID).Msg("Failed to update character last login")
+	}
+	h.selectCharacter(userID, req.CharacterID)
+	h.recordAudit(ctx, database.AuditCharacterSelect, userID, req.CharacterID, ip, nil, nil)
 
 	response := map[string]interface{}{
 		"type": "character_selected",
@@ -308,7 +435,7 @@ func (h *CharacterHandler) handleSelectCharacterWS(data json.RawMessage, userID
 }
 
 // handleCreateCharacterWS gère la création d'un personnage via WebSocket
-func (h *CharacterHandler) handleCreateCharacterWS(data json.RawMessage, userID int) ([]byte, error) {
+func (h *CharacterHandler) handleCreateCharacterWS(ctx context.Context, data json.RawMessage, userID int, ip string) ([]byte, error) {
 	var req models.CreateCharacterRequest
 	if err := json.Unmarshal(data, &req); err != nil {
 		return h.createErrorResponse("Données invalides")
@@ -318,10 +445,12 @@ func (h *CharacterHandler) handleCreateCharacterWS(data json.RawMessage, userID
 		return h.createErrorResponse(err.Error())
 	}
 
-	character, err := h.characterRepo.CreateCharacter(userID, req)
+	character, err := h.characterRepo.CreateCharacter(ctx, userID, req)
 	if err != nil {
-		return h.createErrorResponse(err.Error())
+		_, message := characterError(err, "Erreur lors de la création du personnage")
+		return h.createErrorResponse(message)
 	}
+	h.recordAudit(ctx, database.AuditCharacterCreate, userID, character.ID, ip, nil, character)
 
 	response := map[string]interface{}{
 		"type": "character_created",
@@ -334,6 +463,306 @@ func (h *CharacterHandler) handleCreateCharacterWS(data json.RawMessage, userID
 	return json.Marshal(response)
 }
 
+// handleRestoreCharacterWS gère la restauration d'un personnage supprimé via WebSocket
+func (h *CharacterHandler) handleRestoreCharacterWS(ctx context.Context, data json.RawMessage, userID int, ip string) ([]byte, error) {
+	var req struct {
+		CharacterID int `json:"character_id"`
+	}
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return h.createErrorResponse("Données invalides")
+	}
+
+	character, err := h.characterRepo.RestoreCharacter(ctx, req.CharacterID, userID)
+	if err != nil {
+		_, message := restoreError(err)
+		return h.createErrorResponse(message)
+	}
+	h.recordAudit(ctx, database.AuditCharacterRestore, userID, req.CharacterID, ip, nil, character)
+
+	response := map[string]interface{}{
+		"type": "character_restored",
+		"data": map[string]interface{}{
+			"success":   true,
+			"character": character,
+		},
+	}
+
+	return json.Marshal(response)
+}
+
+// characterPositionMessage est la position envoyée par le client dans player_move et change_map.
+type characterPositionMessage struct {
+	CharacterID int `json:"character_id"`
+	MapX        int `json:"map_x"`
+	MapY        int `json:"map_y"`
+	PosX        int `json:"pos_x"`
+	PosY        int `json:"pos_y"`
+}
+
+// handlePlayerMoveWS enregistre un déplacement du personnage sélectionné. Aucune réponse n'est envoyée
+// en cas de succès : les déplacements sont trop fréquents pour être acquittés.
+func (h *CharacterHandler) handlePlayerMoveWS(ctx context.Context, data json.RawMessage, userID int) ([]byte, error) {
+	var req characterPositionMessage
+	if err := json.Unmarshal(data, &req); err != nil {
+		return h.createErrorResponse("Données invalides")
+	}
+
+	// Pas de lecture en base par déplacement : seul le personnage sélectionné peut bouger
+	if !h.isSelected(userID, req.CharacterID) {
+		return h.createErrorResponse("Personnage non sélectionné")
+	}
+
+	if err := h.MoveCharacter(ctx, req.CharacterID, req.MapX, req.MapY, req.PosX, req.PosY); err != nil {
+		_, message := characterError(err, "Erreur lors du déplacement du personnage")
+		return h.createErrorResponse(message)
+	}
+	return nil, nil
+}
+
+// handleChangeMapWS enregistre l'arrivée du personnage sélectionné sur une nouvelle map.
+func (h *CharacterHandler) handleChangeMapWS(ctx context.Context, data json.RawMessage, userID int, ip string) ([]byte, error) {
+	var req characterPositionMessage
+	if err := json.Unmarshal(data, &req); err != nil {
+		return h.createErrorResponse("Données invalides")
+	}
+
+	if !h.isSelected(userID, req.CharacterID) {
+		return h.createErrorResponse("Personnage non sélectionné")
+	}
+
+	if err := h.ChangeMap(ctx, userID, req.CharacterID, req.MapX, req.MapY, req.PosX, req.PosY, ip); err != nil {
+		_, message := characterError(err, "Erreur lors du changement de map")
+		return h.createErrorResponse(message)
+	}
+
+	response := map[string]interface{}{
+		"type": "map_changed",
+		"data": map[string]interface{}{
+			"success": true,
+			"map_x":   req.MapX,
+			"map_y":   req.MapY,
+			"pos_x":   req.PosX,
+			"pos_y":   req.PosY,
+		},
+	}
+
+	return json.Marshal(response)
+}
+
+// MoveCharacter enregistre un déplacement du personnage sur sa map (messages player_move).
+// Avec un cache de positions, l'écriture en base est différée. Retourne database.ErrInvalidPosition
+// pour une position hors des limites (models.ValidPosition).
+func (h *CharacterHandler) MoveCharacter(ctx context.Context, characterID, mapX, mapY, posX, posY int) error {
+	if !models.ValidPosition(mapX, mapY, posX, posY) {
+		return database.ErrInvalidPosition
+	}
+	return h.characterRepo.UpdateCharacterPosition(ctx, characterID, mapX, mapY, posX, posY)
+}
+
+// CharacterDisconnected écrit immédiatement la dernière position du personnage à la déconnexion du joueur.
+func (h *CharacterHandler) CharacterDisconnected(ctx context.Context, characterID int) error {
+	if h.positions == nil {
+		return nil
+	}
+	return h.positions.FlushCharacter(ctx, characterID)
+}
+
+// UserDisconnected est appelé à la fermeture de la dernière connexion de jeu de l'utilisateur
+// (auth.ConnectionRegistry.OnUserDisconnected) : la position de son personnage sélectionné est écrite.
+func (h *CharacterHandler) UserDisconnected(ctx context.Context, userID int) error {
+	h.mu.Lock()
+	characterID, ok := h.selected[userID]
+	delete(h.selected, userID)
+	h.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return h.CharacterDisconnected(ctx, characterID)
+}
+
+// selectCharacter retient le personnage joué par l'utilisateur.
+func (h *CharacterHandler) selectCharacter(userID, characterID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.selected[userID] = characterID
+}
+
+// deselectCharacter oublie la sélection de l'utilisateur si c'est characterID (personnage supprimé).
+func (h *CharacterHandler) deselectCharacter(userID, characterID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.selected[userID] == characterID {
+		delete(h.selected, userID)
+	}
+}
+
+// isSelected indique si characterID est le personnage sélectionné par l'utilisateur.
+func (h *CharacterHandler) isSelected(userID, characterID int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	selected, ok := h.selected[userID]
+	return ok && selected == characterID
+}
+
+// GainExperience ajoute de l'expérience au personnage et le fait monter de niveau autant que possible
+// (récompenses de combat, de quête, gains accordés par l'administration). Un gain concurrent d'une autre
+// mise à jour des stats est rejoué au lieu d'être perdu : par database.ModifyCharacter sans Transactor,
+// par la transaction elle-même sinon. Avec un Transactor, les sorts de classe débloqués par les niveaux
+// gagnés sont appris dans la même transaction.
+func (h *CharacterHandler) GainExperience(ctx context.Context, characterID int, amount int64) (*models.Character, error) {
+	if h.transactor == nil {
+		return database.ModifyCharacter(ctx, h.characterRepo, characterID, gainExperience(amount, new(int)))
+	}
+
+	var character *models.Character
+	err := h.transactor.WithTx(ctx, func(tx database.Stores) error {
+		var level int
+		var err error
+		character, err = database.ModifyCharacterOnce(ctx, tx.Characters(), characterID, gainExperience(amount, &level))
+		if err != nil || character.Level == level {
+			return err
+		}
+		_, err = tx.Spells().LearnClassSpells(ctx, characterID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return character, nil
+}
+
+// gainExperience retourne la mutation qui ajoute amount d'expérience et fait monter de niveau ;
+// le niveau avant le gain est écrit dans level.
+func gainExperience(amount int64, level *int) func(*models.Character) error {
+	return func(c *models.Character) error {
+		*level = c.Level
+		c.Experience += amount
+		for c.CanLevelUp() {
+			c.LevelUp()
+		}
+		return nil
+	}
+}
+
+// ChangeMap enregistre l'arrivée d'un personnage sur une nouvelle map (messages change_map) ;
+// les déplacements sur une même map ne sont pas audités. Retourne database.ErrInvalidPosition
+// pour une position hors des limites.
+func (h *CharacterHandler) ChangeMap(ctx context.Context, userID, characterID, mapX, mapY, posX, posY int, ip string) error {
+	if !models.ValidPosition(mapX, mapY, posX, posY) {
+		return database.ErrInvalidPosition
+	}
+
+	character, err := h.characterRepo.GetCharacterByID(ctx, characterID)
+	if err != nil {
+		return err
+	}
+	if character.UserID != userID {
+		return database.ErrNotFound
+	}
+
+	if err := h.characterRepo.UpdateCharacterPosition(ctx, characterID, mapX, mapY, posX, posY); err != nil {
+		return err
+	}
+
+	before := characterPosition{character.MapX, character.MapY, character.PosX, character.PosY}
+	after := characterPosition{mapX, mapY, posX, posY}
+	h.recordAudit(ctx, database.AuditCharacterMove, userID, characterID, ip, before, after)
+	return nil
+}
+
+// characterPosition est l'état enregistré dans le journal d'audit pour un changement de map.
+type characterPosition struct {
+	MapX int `json:"map_x"`
+	MapY int `json:"map_y"`
+	PosX int `json:"pos_x"`
+	PosY int `json:"pos_y"`
+}
+
+// recordAudit ajoute une action de l'utilisateur sur un de ses personnages au journal d'audit.
+// Un échec d'écriture est journalisé mais n'annule pas l'action.
+func (h *CharacterHandler) recordAudit(ctx context.Context, action string, userID, characterID int, ip string, before, after interface{}) {
+	if h.audit == nil {
+		return
+	}
+	entry := &database.AuditEntry{
+		ActorID:     &userID,
+		Action:      action,
+		UserID:      &userID,
+		CharacterID: &characterID,
+		Before:      database.AuditSnapshot(before),
+		After:       database.AuditSnapshot(after),
+		IPAddress:   ip,
+	}
+	if err := h.audit.AppendAuditEntry(ctx, entry); err != nil {
+		h.logger.Error().Err(err).Str("action", action).Int("character_id", characterID).Msg("Failed to write audit log")
+	}
+}
+
+// auditSnapshot retourne l'état actuel d'un personnage pour le journal d'audit, nil si l'audit est désactivé.
+func (h *CharacterHandler) auditSnapshot(ctx context.Context, characterID int) interface{} {
+	if h.audit == nil {
+		return nil
+	}
+	character, err := h.characterRepo.GetCharacterByID(ctx, characterID)
+	if err != nil {
+		return nil
+	}
+	return character
+}
+
+// listCharacters récupère les personnages actifs et les personnages supprimés encore restaurables.
+func (h *CharacterHandler) listCharacters(ctx context.Context, userID int) (characters, deleted []models.Character, err error) {
+	characters, err = h.characterRepo.GetCharactersByUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	deleted, err = h.characterRepo.GetDeletedCharactersByUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return characters, deleted, nil
+}
+
+// restoreError traduit une erreur de restauration en statut HTTP et message client.
+func restoreError(err error) (int, string) {
+	if errors.Is(err, database.ErrNotFound) {
+		return http.StatusNotFound, "Personnage non trouvé ou délai de restauration expiré"
+	}
+	return characterError(err, "Erreur lors de la restauration du personnage")
+}
+
+// characterError traduit une erreur du CharacterStore en statut HTTP et message client.
+// Les erreurs inattendues ne sont pas exposées : fallback est renvoyé à la place.
+func characterError(err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, database.ErrNameTaken):
+		return http.StatusConflict, "Ce nom de personnage est déjà utilisé"
+	case errors.Is(err, database.ErrCharacterLimit):
+		return http.StatusConflict, fmt.Sprintf("Vous avez déjà atteint la limite de %d personnages", database.MaxCharactersPerUser)
+	case errors.Is(err, database.ErrInvalidClass):
+		return http.StatusBadRequest, "Classe invalide"
+	case errors.Is(err, database.ErrInvalidPosition):
+		return http.StatusBadRequest, "Position invalide"
+	case errors.Is(err, database.ErrNotFound):
+		return http.StatusNotFound, "Personnage non trouvé"
+	}
+	return http.StatusInternalServerError, fallback
+}
+
+// isCharacterBanned indique si le compte ou le personnage est sous le coup d'un bannissement.
+func (h *CharacterHandler) isCharacterBanned(ctx context.Context, userID, characterID int) (bool, error) {
+	if h.sanctions == nil {
+		return false, nil
+	}
+	_, err := h.sanctions.GetActiveSanction(ctx, userID, &characterID, database.SanctionBan)
+	if errors.Is(err, database.ErrSanctionNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 // createErrorResponse crée une réponse d'erreur pour WebSocket
 func (h *CharacterHandler) createErrorResponse(message string) ([]byte, error) {
 	response := map[string]interface{}{
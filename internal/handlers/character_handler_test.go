@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/flumen/flumen_server/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCharacterErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{database.ErrNameTaken, http.StatusConflict},
+		{database.ErrCharacterLimit, http.StatusConflict},
+		{database.ErrInvalidClass, http.StatusBadRequest},
+		{database.ErrInvalidPosition, http.StatusBadRequest},
+		{database.ErrNotFound, http.StatusNotFound},
+		{fmt.Errorf("création du personnage: %w", database.ErrNameTaken), http.StatusConflict},
+		{errors.New("connexion perdue"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		status, message := characterError(tt.err, "fallback")
+		if status != tt.status {
+			t.Errorf("characterError(%v) = %d, attendu %d", tt.err, status, tt.status)
+		}
+		if tt.status == http.StatusInternalServerError && message != "fallback" {
+			t.Errorf("characterError(%v) expose %q au lieu du message par défaut", tt.err, message)
+		}
+	}
+}
+
+func TestRestoreErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{database.ErrNotFound, http.StatusNotFound},
+		{database.ErrCharacterLimit, http.StatusConflict},
+		{database.ErrNameTaken, http.StatusConflict},
+		{errors.New("connexion perdue"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if status, _ := restoreError(tt.err); status != tt.status {
+			t.Errorf("restoreError(%v) = %d, attendu %d", tt.err, status, tt.status)
+		}
+	}
+
+	if _, message := restoreError(database.ErrNotFound); !strings.Contains(message, "délai de restauration") {
+		t.Errorf("restoreError(ErrNotFound) = %q, attendu une mention du délai de restauration", message)
+	}
+}
+
+// testServer sert les routes du CharacterHandler sur un MemoryStore, derrière le middleware JWT.
+// Le store et le JWTService partagent la même horloge.
+type testServer struct {
+	t      *testing.T
+	app    *fiber.App
+	store  *database.MemoryStore
+	jwt    *auth.JWTService
+	now    time.Time
+	userID int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	registry, err := models.NewClassRegistry([]models.ClassInfo{{
+		ID:        "warrior",
+		Name:      "Guerrier",
+		IconPath:  "res://icons/warrior.png",
+		BaseStats: models.ClassStats{Vitality: 20, Strength: 15},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := models.Classes()
+	models.SetClassRegistry(registry)
+	t.Cleanup(func() { models.SetClassRegistry(previous) })
+
+	keys, err := auth.KeyRingFromSecret("test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{t: t, store: database.NewMemoryStore(), now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	s.store.SetClock(s.clock)
+	s.jwt = auth.NewJWTService(keys, auth.JWTConfig{Clock: s.clock})
+
+	user := &database.User{Username: "alice", Email: "alice@example.com"}
+	if err := s.store.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	s.userID = user.ID
+
+	s.app = fiber.New()
+	NewCharacterHandler(s.store).RegisterRoutes(s.app.Group("/api/v1", s.jwt.Middleware()))
+	return s
+}
+
+func (s *testServer) clock() time.Time { return s.now }
+
+// do envoie une requête authentifiée avec un token émis à l'heure courante et retourne le statut et le corps.
+func (s *testServer) do(method, path, body string) (int, map[string]interface{}) {
+	s.t.Helper()
+
+	tokens, err := s.jwt.GenerateTokens(auth.Identity{UserID: s.userID, Username: "alice"}, "session")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tokens.AccessToken)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		s.t.Fatalf("%s %s: réponse illisible: %v", method, path, err)
+	}
+	return resp.StatusCode, decoded
+}
+
+func (s *testServer) createCharacter(name string) (int, map[string]interface{}) {
+	s.t.Helper()
+	return s.do(http.MethodPost, "/api/v1/characters", fmt.Sprintf(`{"name":%q,"class":"warrior"}`, name))
+}
+
+func TestCreateCharacterNameTaken(t *testing.T) {
+	s := newTestServer(t)
+
+	if status, body := s.createCharacter("Aragorn"); status != http.StatusOK {
+		t.Fatalf("création: %d %v", status, body)
+	}
+	status, body := s.createCharacter("ARAGORN")
+	if status != http.StatusConflict {
+		t.Fatalf("nom en double: %d %v, attendu 409", status, body)
+	}
+	if body["success"] != false {
+		t.Fatalf("success = %v, attendu false", body["success"])
+	}
+}
+
+func TestCreateCharacterLimit(t *testing.T) {
+	s := newTestServer(t)
+
+	for i := 0; i < database.MaxCharactersPerUser; i++ {
+		if status, body := s.createCharacter(fmt.Sprintf("Hero%d", i)); status != http.StatusOK {
+			t.Fatalf("création %d: %d %v", i, status, body)
+		}
+	}
+	if status, body := s.createCharacter("OneTooMany"); status != http.StatusConflict {
+		t.Fatalf("limite atteinte: %d %v, attendu 409", status, body)
+	}
+}
+
+func TestRestoreCharacterGracePeriod(t *testing.T) {
+	s := newTestServer(t)
+
+	restoreAfter := func(name string, wait time.Duration) (int, map[string]interface{}) {
+		status, body := s.createCharacter(name)
+		if status != http.StatusOK {
+			t.Fatalf("création: %d %v", status, body)
+		}
+		id := int(body["character"].(map[string]interface{})["id"].(float64))
+
+		if status, body := s.do(http.MethodDelete, fmt.Sprintf("/api/v1/characters/%d", id), ""); status != http.StatusOK {
+			t.Fatalf("suppression: %d %v", status, body)
+		}
+		s.now = s.now.Add(wait)
+		return s.do(http.MethodPost, fmt.Sprintf("/api/v1/characters/%d/restore", id), "")
+	}
+
+	if status, body := restoreAfter("Legolas", database.DefaultDeleteGracePeriod-time.Hour); status != http.StatusOK {
+		t.Fatalf("restauration pendant le délai: %d %v", status, body)
+	}
+	if status, body := restoreAfter("Gimli", database.DefaultDeleteGracePeriod+time.Hour); status != http.StatusNotFound {
+		t.Fatalf("restauration après le délai: %d %v, attendu 404", status, body)
+	}
+}
+
+func TestMoveCharacterRejectsOutOfRangePosition(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	character, err := s.store.CreateCharacter(ctx, s.userID, models.CreateCharacterRequest{Name: "Pippin", Class: "warrior"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	positions := database.NewPositionCache(s.store)
+	h := NewCharacterHandler(s.store)
+	h.SetPositionCache(positions)
+
+	for _, pos := range [][4]int{{0, 0, models.MapCells, 0}, {0, 0, 0, -1}, {models.MaxMapCoordinate + 1, 0, 0, 0}} {
+		if err := h.MoveCharacter(ctx, character.ID, pos[0], pos[1], pos[2], pos[3]); !errors.Is(err, database.ErrInvalidPosition) {
+			t.Errorf("MoveCharacter(%v) = %v, attendu ErrInvalidPosition", pos, err)
+		}
+		if err := h.ChangeMap(ctx, s.userID, character.ID, pos[0], pos[1], pos[2], pos[3], ""); !errors.Is(err, database.ErrInvalidPosition) {
+			t.Errorf("ChangeMap(%v) = %v, attendu ErrInvalidPosition", pos, err)
+		}
+	}
+	if pending := positions.Stats().Pending; pending != 0 {
+		t.Fatalf("%d positions invalides mises en cache", pending)
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/flumen/flumen_server/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetSpellStore active les routes des sorts des personnages (voir RegisterRoutes).
+func (h *CharacterHandler) SetSpellStore(spells database.SpellStore) {
+	h.spells = spells
+}
+
+// registerSpellRoutes enregistre les routes des sorts : liste, apprentissage et oubli.
+// La montée de niveau d'un sort n'est pas exposée aux joueurs (voir LevelUpSpell).
+func (h *CharacterHandler) registerSpellRoutes(router fiber.Router) {
+	router.Get("/characters/:id/spells", h.GetSpells)
+	router.Post("/characters/:id/spells/:spellId", h.LearnSpell)
+	router.Delete("/characters/:id/spells/:spellId", h.ForgetSpell)
+}
+
+// GetSpells liste les sorts appris par un personnage de l'utilisateur
+func (h *CharacterHandler) GetSpells(c *fiber.Ctx) error {
+	characterID, ok := h.spellCharacter(c)
+	if !ok {
+		return nil
+	}
+
+	spells, err := h.spells.GetCharacterSpells(c.Context(), characterID)
+	if err != nil {
+		status, message := spellError(err, "Erreur lors de la récupération des sorts")
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   message,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"spells":  spells,
+	})
+}
+
+// LearnSpell fait apprendre un sort accessible à la classe et au niveau du personnage
+func (h *CharacterHandler) LearnSpell(c *fiber.Ctx) error {
+	characterID, ok := h.spellCharacter(c)
+	if !ok {
+		return nil
+	}
+
+	spell, err := h.spells.LearnSpell(c.Context(), characterID, c.Params("spellId"))
+	if err != nil {
+		status, message := spellError(err, "Erreur lors de l'apprentissage du sort")
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   message,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"spell":   spell,
+	})
+}
+
+// ForgetSpell retire un sort appris au personnage
+func (h *CharacterHandler) ForgetSpell(c *fiber.Ctx) error {
+	characterID, ok := h.spellCharacter(c)
+	if !ok {
+		return nil
+	}
+
+	if err := h.spells.ForgetSpell(c.Context(), characterID, c.Params("spellId")); err != nil {
+		status, message := spellError(err, "Erreur lors de l'oubli du sort")
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   message,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Sort oublié",
+	})
+}
+
+// LevelUpSpell augmente d'un niveau un sort appris par le personnage. Les joueurs n'y ont pas accès
+// directement : la logique de jeu (points de sort, récompenses) et l'administration l'appellent.
+func (h *CharacterHandler) LevelUpSpell(ctx context.Context, characterID int, spellID string) (*models.CharacterSpell, error) {
+	if h.spells == nil {
+		return nil, errors.New("sorts des personnages non configurés")
+	}
+	return h.spells.LevelUpSpell(ctx, characterID, spellID)
+}
+
+// spellCharacter retourne l'ID du personnage de la route s'il appartient à l'utilisateur authentifié.
+// Sinon la réponse d'erreur est déjà écrite et ok vaut false.
+func (h *CharacterHandler) spellCharacter(c *fiber.Ctx) (characterID int, ok bool) {
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		auth.Unauthorized(c, "", "Token manquant")
+		return 0, false
+	}
+
+	characterID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "ID de personnage invalide",
+		})
+		return 0, false
+	}
+
+	character, err := h.characterRepo.GetCharacterByID(c.Context(), characterID)
+	if err == nil && character.UserID != principal.UserID {
+		// Les personnages des autres comptes ne sont pas révélés
+		err = database.ErrNotFound
+	}
+	if err != nil {
+		status, message := characterError(err, "Erreur lors de la récupération du personnage")
+		c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   message,
+		})
+		return 0, false
+	}
+	return characterID, true
+}
+
+// spellError traduit une erreur du SpellStore en statut HTTP et message client.
+func spellError(err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, database.ErrSpellNotFound):
+		return http.StatusNotFound, "Sort introuvable"
+	case errors.Is(err, database.ErrSpellNotKnown):
+		return http.StatusNotFound, "Sort non appris"
+	case errors.Is(err, database.ErrSpellNotLearnable):
+		return http.StatusForbidden, "Sort non disponible pour ce personnage"
+	case errors.Is(err, database.ErrSpellAlreadyKnown):
+		return http.StatusConflict, "Sort déjà appris"
+	case errors.Is(err, database.ErrSpellMaxLevel):
+		return http.StatusConflict, "Niveau maximum du sort atteint"
+	}
+	return characterError(err, fallback)
+}
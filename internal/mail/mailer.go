@@ -0,0 +1,143 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message est un email texte simple.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envoie des emails transactionnels (vérification, réinitialisation du mot de passe).
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPConfig contient les paramètres de connexion au serveur SMTP.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer envoie les emails via un serveur SMTP (STARTTLS si proposé par le serveur).
+type SMTPMailer struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPMailer crée un Mailer SMTP.
+func NewSMTPMailer(cfg SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{cfg: cfg}
+}
+
+// Send envoie le message. Le contexte n'interrompt pas un envoi déjà commencé.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, m.cfg.From, []string{msg.To}, format(m.cfg.From, msg)); err != nil {
+		return fmt.Errorf("envoi SMTP vers %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// FileMailer écrit chaque email dans un fichier .eml, pour le développement local.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer crée un Mailer qui écrit les emails dans dir.
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+// Send écrit le message dans un nouveau fichier.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), recipient)
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg), 0o644)
+}
+
+// MemoryMailer conserve les emails en mémoire, pour les tests.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryMailer crée un Mailer en mémoire.
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send conserve le message.
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages retourne une copie des emails envoyés.
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// LastTo retourne le dernier email envoyé à to.
+func (m *MemoryMailer) LastTo(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// format construit le message RFC 5322 envoyé sur le réseau ou écrit sur disque.
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(msg.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// headerValue retire les retours à la ligne pour empêcher l'injection d'en-têtes.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
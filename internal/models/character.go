@@ -4,13 +4,22 @@ import (
 	"time"
 )
 
-// CharacterClass représente les classes disponibles
-type CharacterClass string
+// MapCells est le nombre de cases par côté d'une map : pos_x et pos_y vont de 0 à MapCells-1
+// (contraintes CHECK de la table characters).
+const MapCells = 30
+
+// MaxMapCoordinate borne les coordonnées des maps : map_x et map_y vont de -MaxMapCoordinate à MaxMapCoordinate.
+const MaxMapCoordinate = 1000
+
+// ValidPosition indique si la position est dans les limites du monde et de sa map.
+func ValidPosition(mapX, mapY, posX, posY int) bool {
+	return mapX >= -MaxMapCoordinate && mapX <= MaxMapCoordinate &&
+		mapY >= -MaxMapCoordinate && mapY <= MaxMapCoordinate &&
+		posX >= 0 && posX < MapCells && posY >= 0 && posY < MapCells
+}
 
-const (
-	ClassWarrior CharacterClass = "warrior" // Guerrier
-	ClassArcher  CharacterClass = "archer"  // Archer
-)
+// CharacterClass est l'identifiant d'une classe du registre (voir ClassRegistry)
+type CharacterClass string
 
 // Character représente un personnage de joueur
 type Character struct {
@@ -48,71 +57,11 @@ type Character struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 	LastLogin time.Time `json:"last_login" db:"last_login"`
-}
+	Version   int       `json:"version" db:"version"` // Verrouillage optimiste des stats et de l'expérience
 
-// ClassInfo contient les informations sur une classe
-type ClassInfo struct {
-	ID          CharacterClass `json:"id"`
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	BaseStats   ClassStats     `json:"base_stats"`
-	IconPath    string         `json:"icon_path"`
-}
-
-// ClassStats représente les stats de base d'une classe
-type ClassStats struct {
-	Vitality     int `json:"vitality"`
-	Wisdom       int `json:"wisdom"`
-	Strength     int `json:"strength"`
-	Intelligence int `json:"intelligence"`
-	Chance       int `json:"chance"`
-	Agility      int `json:"agility"`
-}
-
-// GetClassInfo retourne les informations d'une classe
-func GetClassInfo(class CharacterClass) ClassInfo {
-	switch class {
-	case ClassWarrior:
-		return ClassInfo{
-			ID:          ClassWarrior,
-			Name:        "Guerrier",
-			Description: "Combattant au corps à corps, maître des armes lourdes",
-			BaseStats: ClassStats{
-				Vitality:     20, // Plus de PV
-				Wisdom:       10,
-				Strength:     15, // Forte en Force
-				Intelligence: 5,
-				Chance:       5,
-				Agility:      10,
-			},
-			IconPath: "res://assets/classes/warrior_icon.png",
-		}
-	case ClassArcher:
-		return ClassInfo{
-			ID:          ClassArcher,
-			Name:        "Archer",
-			Description: "Combattant à distance, précis et agile",
-			BaseStats: ClassStats{
-				Vitality:     15,
-				Wisdom:       10,
-				Strength:     5,
-				Intelligence: 5,
-				Chance:       15, // Forte en Chance (dommages distance)
-				Agility:      15, // Forte en Agilité
-			},
-			IconPath: "res://assets/classes/archer_icon.png",
-		}
-	default:
-		return ClassInfo{}
-	}
-}
-
-// GetAllClasses retourne toutes les classes disponibles
-func GetAllClasses() []ClassInfo {
-	return []ClassInfo{
-		GetClassInfo(ClassWarrior),
-		GetClassInfo(ClassArcher),
-	}
+	// Suppression différée : le personnage reste restaurable jusqu'à PurgeAt
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty" db:"-"` // Calculé avec le délai de grâce
 }
 
 // CalculateStats calcule les stats dérivées du personnage
@@ -133,6 +82,16 @@ func (c *Character) CalculateStats() {
 	c.ExperienceNext = int64(c.Level * c.Level * 100)
 }
 
+// SetPurgeAt calcule la date de purge d'un personnage supprimé à partir du délai de grâce
+func (c *Character) SetPurgeAt(gracePeriod time.Duration) {
+	if c.DeletedAt == nil {
+		c.PurgeAt = nil
+		return
+	}
+	purgeAt := c.DeletedAt.Add(gracePeriod)
+	c.PurgeAt = &purgeAt
+}
+
 // CanLevelUp vérifie si le personnage peut monter de niveau
 func (c *Character) CanLevelUp() bool {
 	return c.Experience >= c.ExperienceNext
@@ -148,12 +107,12 @@ func (c *Character) LevelUp() {
 
 	// Bonus de stats par niveau selon la classe
 	classInfo := GetClassInfo(c.Class)
-	c.Vitality += classInfo.BaseStats.Vitality / 10 // 2 vitalité par niveau pour guerrier
-	c.Wisdom += classInfo.BaseStats.Wisdom / 10
-	c.Strength += classInfo.BaseStats.Strength / 10
-	c.Intelligence += classInfo.BaseStats.Intelligence / 10
-	c.Chance += classInfo.BaseStats.Chance / 10
-	c.Agility += classInfo.BaseStats.Agility / 10
+	c.Vitality += classInfo.Growth.Vitality
+	c.Wisdom += classInfo.Growth.Wisdom
+	c.Strength += classInfo.Growth.Strength
+	c.Intelligence += classInfo.Growth.Intelligence
+	c.Chance += classInfo.Growth.Chance
+	c.Agility += classInfo.Growth.Agility
 
 	// Recalculer les stats
 	c.CalculateStats()
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sync/atomic"
+)
+
+// classIDPattern est le format des identifiants de classe (colonne characters.class, VARCHAR(20)).
+var classIDPattern = regexp.MustCompile(`^[a-z][a-z_]{0,19}$`)
+
+// ClassInfo contient les informations sur une classe
+type ClassInfo struct {
+	ID             CharacterClass `json:"id"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	BaseStats      ClassStats     `json:"base_stats"`      // Stats au niveau 1
+	Growth         ClassStats     `json:"growth"`          // Stats gagnées à chaque niveau
+	StartingSpells []string       `json:"starting_spells"` // Sorts appris à la création
+	IconPath       string         `json:"icon_path"`
+	StartMap       ClassStartMap  `json:"start_map"`
+}
+
+// ClassStats représente les stats de base d'une classe
+type ClassStats struct {
+	Vitality     int `json:"vitality"`
+	Wisdom       int `json:"wisdom"`
+	Strength     int `json:"strength"`
+	Intelligence int `json:"intelligence"`
+	Chance       int `json:"chance"`
+	Agility      int `json:"agility"`
+}
+
+// ClassStartMap est la position de départ des personnages d'une classe
+type ClassStartMap struct {
+	MapX int `json:"map_x"`
+	MapY int `json:"map_y"`
+	PosX int `json:"pos_x"`
+	PosY int `json:"pos_y"`
+}
+
+// ClassRegistry est l'ensemble des classes jouables, chargé au démarrage depuis la table character_classes.
+// Il n'est pas modifié après sa création.
+type ClassRegistry struct {
+	classes map[CharacterClass]ClassInfo
+	order   []CharacterClass // Ordre d'affichage
+}
+
+// NewClassRegistry valide les classes et construit le registre. Les classes sont listées dans l'ordre donné.
+func NewClassRegistry(classes []ClassInfo) (*ClassRegistry, error) {
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("registre des classes vide")
+	}
+
+	r := &ClassRegistry{classes: make(map[CharacterClass]ClassInfo, len(classes))}
+	for _, class := range classes {
+		if err := class.validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := r.classes[class.ID]; exists {
+			return nil, fmt.Errorf("classe %q: déclarée plusieurs fois", class.ID)
+		}
+		r.classes[class.ID] = class
+		r.order = append(r.order, class.ID)
+	}
+	return r, nil
+}
+
+// Get retourne les informations d'une classe
+func (r *ClassRegistry) Get(class CharacterClass) (ClassInfo, bool) {
+	if r == nil {
+		return ClassInfo{}, false
+	}
+	info, ok := r.classes[class]
+	return info, ok
+}
+
+// All retourne toutes les classes, dans l'ordre d'affichage
+func (r *ClassRegistry) All() []ClassInfo {
+	if r == nil {
+		return []ClassInfo{}
+	}
+	classes := make([]ClassInfo, 0, len(r.order))
+	for _, id := range r.order {
+		classes = append(classes, r.classes[id])
+	}
+	return classes
+}
+
+// Len retourne le nombre de classes
+func (r *ClassRegistry) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.order)
+}
+
+// validate vérifie la cohérence d'une classe. L'existence des sorts de départ est vérifiée au chargement.
+func (c ClassInfo) validate() error {
+	if !classIDPattern.MatchString(string(c.ID)) {
+		return fmt.Errorf("classe %q: identifiant invalide", c.ID)
+	}
+	if c.Name == "" {
+		return fmt.Errorf("classe %q: nom manquant", c.ID)
+	}
+	if c.IconPath == "" {
+		return fmt.Errorf("classe %q: icône manquante", c.ID)
+	}
+	if !c.BaseStats.valid() {
+		return fmt.Errorf("classe %q: stats de base négatives", c.ID)
+	}
+	if !c.Growth.valid() {
+		return fmt.Errorf("classe %q: gains par niveau négatifs", c.ID)
+	}
+	if c.StartMap.PosX < 0 || c.StartMap.PosY < 0 {
+		return fmt.Errorf("classe %q: position de départ invalide", c.ID)
+	}
+
+	seen := make(map[string]bool, len(c.StartingSpells))
+	for _, spellID := range c.StartingSpells {
+		if spellID == "" || seen[spellID] {
+			return fmt.Errorf("classe %q: sort de départ %q vide ou en double", c.ID, spellID)
+		}
+		seen[spellID] = true
+	}
+	return nil
+}
+
+func (s ClassStats) valid() bool {
+	return s.Vitality >= 0 && s.Wisdom >= 0 && s.Strength >= 0 &&
+		s.Intelligence >= 0 && s.Chance >= 0 && s.Agility >= 0
+}
+
+// classes est le registre utilisé par GetClassInfo et GetAllClasses, installé au démarrage
+var classes atomic.Pointer[ClassRegistry]
+
+// SetClassRegistry installe le registre des classes. À appeler au démarrage, avant de servir des requêtes.
+func SetClassRegistry(registry *ClassRegistry) {
+	classes.Store(registry)
+}
+
+// Classes retourne le registre des classes installé, nil avant SetClassRegistry
+func Classes() *ClassRegistry {
+	return classes.Load()
+}
+
+// GetClassInfo retourne les informations d'une classe, ou une ClassInfo vide si elle est inconnue
+func GetClassInfo(class CharacterClass) ClassInfo {
+	info, _ := Classes().Get(class)
+	return info
+}
+
+// GetAllClasses retourne toutes les classes disponibles
+func GetAllClasses() []ClassInfo {
+	return Classes().All()
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MaxSpellLevel est le niveau maximum d'un sort.
+const MaxSpellLevel = 6
+
+// SpellTemplate est la définition d'un sort (données de référence de spell_templates)
+type SpellTemplate struct {
+	ID          string          `json:"id" db:"id"`
+	Class       string          `json:"class" db:"class"` // Identifiant de classe (character_classes.id), vide pour un sort commun
+	Name        string          `json:"name" db:"name"`
+	Description string          `json:"description" db:"description"`
+	MinLevel    int             `json:"min_level" db:"min_level"` // Niveau de personnage requis
+	PACost      int             `json:"pa_cost" db:"pa_cost"`     // Coût en Points d'Action
+	RangeMin    int             `json:"range_min" db:"range_min"`
+	RangeMax    int             `json:"range_max" db:"range_max"`
+	Area        string          `json:"area" db:"area"`       // Zone d'effet (SELF, SINGLE, LINE, CONE...)
+	Effects     json.RawMessage `json:"effects" db:"effects"` // Effets versionnés ({"v":1,"data":[...]})
+}
+
+// CharacterSpell est un sort appris par un personnage
+type CharacterSpell struct {
+	SpellTemplate
+	CharacterID  int       `json:"character_id" db:"character_id"`
+	LevelLearned int       `json:"level_learned" db:"level_learned"` // Niveau du personnage à l'apprentissage
+	SpellLevel   int       `json:"spell_level" db:"spell_level"`
+	XP           int       `json:"xp" db:"xp"`
+	LearnedAt    time.Time `json:"learned_at" db:"learned_at"`
+}
+
+// LearnableBy indique si un personnage de cette classe et de ce niveau peut apprendre le sort.
+func (s *SpellTemplate) LearnableBy(class CharacterClass, level int) bool {
+	return (s.Class == "" || s.Class == string(class)) && level >= s.MinLevel
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/flumen/flumen_server/internal/mail"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	emailVerificationTTL = time.Hour * 48
+	passwordResetTTL     = time.Hour * 1
+	mailSendTimeout      = time.Second * 30
+)
+
+type TokenRequest struct {
+	Token string `json:"token"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// newMailer choisit l'implémentation d'envoi des emails : SMTP si configuré, sinon fichiers locaux.
+func (s *Server) newMailer() mail.Mailer {
+	if s.config.SMTPHost != "" {
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     s.config.SMTPHost,
+			Port:     s.config.SMTPPort,
+			Username: s.config.SMTPUsername,
+			Password: s.config.SMTPPassword,
+			From:     s.config.MailFrom,
+		})
+	}
+	s.logger.Warn().Str("dir", s.config.MailDir).Msg("SMTP not configured, emails are written to disk")
+	return mail.NewFileMailer(s.config.MailDir, s.config.MailFrom)
+}
+
+// sendMail envoie l'email en arrière-plan pour que le temps de réponse ne dépende pas du serveur SMTP.
+func (s *Server) sendMail(msg mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			s.logger.Error().Err(err).Str("to", msg.To).Msg("Failed to send email")
+		}
+	}()
+}
+
+// issueUserToken crée un token à usage unique et retourne sa valeur en clair, à n'envoyer que par email.
+func (s *Server) issueUserToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.db.CreateUserToken(ctx, &database.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendVerificationEmail envoie le lien de vérification de l'email.
+func (s *Server) sendVerificationEmail(ctx context.Context, user *database.User) error {
+	token, err := s.issueUserToken(ctx, user.ID, database.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(s.config.PublicURL, "/"), url.QueryEscape(token))
+	s.sendMail(mail.Message{
+		To:      user.Email,
+		Subject: "Flumen - Vérifiez votre adresse email",
+		Body: fmt.Sprintf("Bonjour %s,\n\nConfirmez votre adresse email en ouvrant ce lien (valable 48 heures) :\n%s\n\n"+
+			"Si vous n'avez pas créé de compte Flumen, ignorez cet email.\n", user.Username, link),
+	})
+	return nil
+}
+
+// verifyEmailRequestHandler renvoie un email de vérification à l'utilisateur connecté.
+func (s *Server) verifyEmailRequestHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+
+	user, err := s.users.GetUserByID(c.Context(), principal.UserID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get user")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if user.EmailVerifiedAt != nil {
+		return c.JSON(fiber.Map{"message": "Email already verified"})
+	}
+
+	if err := s.sendVerificationEmail(c.Context(), user); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to issue verification token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Verification email sent"})
+}
+
+// verifyEmailHandler consomme le token de vérification reçu par email.
+func (s *Server) verifyEmailHandler(c *fiber.Ctx) error {
+	req := new(TokenRequest)
+	if err := c.BodyParser(req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Token required"})
+	}
+
+	token, err := s.db.ConsumeUserToken(c.Context(), database.TokenPurposeEmailVerification, auth.HashOpaqueToken(req.Token))
+	if err != nil {
+		if errors.Is(err, database.ErrUserTokenInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired token"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to consume verification token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	if err := s.db.MarkEmailVerified(c.Context(), token.UserID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to mark email verified")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	s.audit(c, token.UserID, database.AuditEmailVerified, token.UserID, nil, nil)
+
+	return c.JSON(fiber.Map{"message": "Email verified"})
+}
+
+// passwordResetRequestHandler envoie un lien de réinitialisation.
+// La réponse est identique que l'email existe ou non, pour ne pas révéler les comptes.
+func (s *Server) passwordResetRequestHandler(c *fiber.Ctx) error {
+	req := new(PasswordResetRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	// Chaque demande envoie un email : limitée par IP et par adresse comme les connexions,
+	// que le compte existe ou non
+	ip := c.IP()
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	throttleKey := passwordResetThrottleKey(email)
+	if wait := s.loginGuard.Check(ip, throttleKey); wait > 0 {
+		return tooManyRequests(c, wait, "Too many password reset requests")
+	}
+	s.loginGuard.RecordFailure(ip, throttleKey)
+
+	if isValidEmail(email) {
+		s.sendPasswordResetEmail(c.Context(), email)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "If the email is registered, a reset link has been sent"})
+}
+
+// passwordResetThrottleKey est l'identifiant de limitation des demandes de réinitialisation d'une adresse.
+func passwordResetThrottleKey(email string) string {
+	return "password-reset:" + email
+}
+
+// sendPasswordResetEmail envoie le lien de réinitialisation si l'email correspond à un compte.
+func (s *Server) sendPasswordResetEmail(ctx context.Context, email string) {
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			s.logger.Error().Err(err).Msg("Failed to get user")
+		}
+		return
+	}
+
+	token, err := s.issueUserToken(ctx, user.ID, database.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to issue password reset token")
+		return
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(s.config.PublicURL, "/"), url.QueryEscape(token))
+	s.sendMail(mail.Message{
+		To:      user.Email,
+		Subject: "Flumen - Réinitialisation du mot de passe",
+		Body: fmt.Sprintf("Bonjour %s,\n\nPour choisir un nouveau mot de passe, ouvrez ce lien (valable 1 heure) :\n%s\n\n"+
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez cet email : votre mot de passe reste inchangé.\n", user.Username, link),
+	})
+}
+
+// passwordResetConfirmHandler applique le nouveau mot de passe et déconnecte toutes les sessions.
+func (s *Server) passwordResetConfirmHandler(c *fiber.Ctx) error {
+	req := new(PasswordResetConfirmRequest)
+	if err := c.BodyParser(req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Token required"})
+	}
+
+	// Valider le mot de passe avant de consommer le token, pour ne pas le perdre sur une erreur de saisie
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":  "Validation failed",
+			"fields": []FieldError{{"password", CodeInvalidLength, "Password must be between 8 and 72 characters"}},
+		})
+	}
+	if !isStrongPassword(req.Password, "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":  "Validation failed",
+			"fields": []FieldError{{"password", CodeWeakPassword, "Password must mix at least 3 of lowercase, uppercase, digits and symbols"}},
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to hash password")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	token, err := s.db.ConsumeUserToken(c.Context(), database.TokenPurposePasswordReset, auth.HashOpaqueToken(req.Token))
+	if err != nil {
+		if errors.Is(err, database.ErrUserTokenInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired token"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to consume password reset token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	if err := s.db.UpdatePassword(c.Context(), token.UserID, string(hashedPassword)); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to update password")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	// Le lien prouve la possession de l'email : l'email est donc vérifié
+	if err := s.db.MarkEmailVerified(c.Context(), token.UserID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to mark email verified")
+	}
+
+	if err := s.db.RevokeAllUserSessions(c.Context(), token.UserID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to revoke user sessions")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	s.connections.DisconnectUser(token.UserID)
+	s.audit(c, token.UserID, database.AuditPasswordReset, token.UserID, nil, nil)
+
+	return c.JSON(fiber.Map{"message": "Password updated"})
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// getUserRolesHandler retourne les rôles d'un utilisateur.
+func (s *Server) getUserRolesHandler(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	roles, err := s.db.GetUserRoles(c.Context(), userID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get user roles")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.JSON(fiber.Map{"userId": userID, "roles": roles})
+}
+
+// grantRoleHandler attribue un rôle. Il prend effet au prochain refresh du token de l'utilisateur.
+func (s *Server) grantRoleHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	userID, role, ok := s.roleParams(c)
+	if !ok {
+		return nil
+	}
+
+	if _, err := s.users.GetUserByID(c.Context(), userID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to get user")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	if err := s.db.GrantRole(c.Context(), userID, role, principal.UserID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to grant role")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	s.logger.Info().Int("user_id", userID).Str("role", role).Int("granted_by", principal.UserID).Msg("Role granted")
+	s.audit(c, principal.UserID, database.AuditRoleGranted, userID, nil, fiber.Map{"role": role})
+	return c.JSON(fiber.Map{"message": "Role granted"})
+}
+
+// revokeRoleHandler retire un rôle et déconnecte l'utilisateur pour que ses tokens perdent le rôle immédiatement.
+func (s *Server) revokeRoleHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	userID, role, ok := s.roleParams(c)
+	if !ok {
+		return nil
+	}
+
+	revoked, err := s.db.RevokeRole(c.Context(), userID, role)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to revoke role")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if !revoked {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User does not have this role"})
+	}
+
+	if err := s.db.RevokeAllUserSessions(c.Context(), userID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to revoke user sessions")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	s.connections.DisconnectUser(userID)
+
+	s.logger.Info().Int("user_id", userID).Str("role", role).Int("revoked_by", principal.UserID).Msg("Role revoked")
+	s.audit(c, principal.UserID, database.AuditRoleRevoked, userID, fiber.Map{"role": role}, nil)
+	return c.JSON(fiber.Map{"message": "Role revoked"})
+}
+
+// roleParams lit l'ID utilisateur et le rôle de la route. En cas d'erreur la réponse est déjà écrite.
+func (s *Server) roleParams(c *fiber.Ctx) (userID int, role string, ok bool) {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+		return 0, "", false
+	}
+
+	role = c.Params("role")
+	if !auth.IsValidRole(role) || !auth.IsStaffRole(role) {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid role"})
+		return 0, "", false
+	}
+	return userID, role, true
+}
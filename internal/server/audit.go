@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// audit ajoute une action effectuée par actorID sur le compte userID au journal d'audit.
+// Un échec d'écriture est journalisé mais n'annule pas l'action.
+func (s *Server) audit(c *fiber.Ctx, actorID int, action string, userID int, before, after interface{}) {
+	s.appendAudit(c.Context(), &database.AuditEntry{
+		ActorID:   &actorID,
+		Action:    action,
+		UserID:    &userID,
+		Before:    database.AuditSnapshot(before),
+		After:     database.AuditSnapshot(after),
+		IPAddress: c.IP(),
+	})
+}
+
+func (s *Server) appendAudit(ctx context.Context, entry *database.AuditEntry) {
+	if err := s.db.AppendAuditEntry(ctx, entry); err != nil {
+		s.logger.Error().Err(err).Str("action", entry.Action).Msg("Failed to write audit log")
+	}
+}
+
+// getAuditLogHandler recherche dans le journal d'audit pour le support.
+// Filtres : userId, characterId, action (exacte ou préfixe "character."), from et to (RFC 3339),
+// beforeId (pagination) et limit.
+func (s *Server) getAuditLogHandler(c *fiber.Ctx) error {
+	var filter database.AuditFilter
+	var ok bool
+
+	if filter.UserID, ok = optionalIntQuery(c, "userId"); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+	if filter.CharacterID, ok = optionalIntQuery(c, "characterId"); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid character ID"})
+	}
+	filter.Action = c.Query("action")
+	if filter.From, ok = optionalTimeQuery(c, "from"); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid from date"})
+	}
+	if filter.To, ok = optionalTimeQuery(c, "to"); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid to date"})
+	}
+	filter.BeforeID = int64(c.QueryInt("beforeId"))
+	filter.Limit = c.QueryInt("limit")
+
+	entries, err := s.db.QueryAuditLog(c.Context(), filter)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to query audit log")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	result := make([]fiber.Map, len(entries))
+	for i := range entries {
+		result[i] = auditJSON(&entries[i])
+	}
+	response := fiber.Map{"entries": result}
+	if len(entries) > 0 {
+		response["nextBeforeId"] = entries[len(entries)-1].ID
+	}
+	return c.JSON(response)
+}
+
+// auditJSON formate une entrée du journal d'audit pour l'API d'administration.
+func auditJSON(entry *database.AuditEntry) fiber.Map {
+	return fiber.Map{
+		"id":          entry.ID,
+		"actorId":     entry.ActorID,
+		"action":      entry.Action,
+		"userId":      entry.UserID,
+		"characterId": entry.CharacterID,
+		"before":      entry.Before,
+		"after":       entry.After,
+		"ipAddress":   entry.IPAddress,
+		"createdAt":   entry.CreatedAt,
+	}
+}
+
+// optionalIntQuery lit un entier facultatif de la query string. ok vaut false s'il est invalide.
+func optionalIntQuery(c *fiber.Ctx, key string) (value *int, ok bool) {
+	if c.Query(key) == "" {
+		return nil, true
+	}
+	n := c.QueryInt(key, -1)
+	if n < 0 {
+		return nil, false
+	}
+	return &n, true
+}
+
+// optionalTimeQuery lit une date RFC 3339 facultative de la query string. ok vaut false si elle est invalide.
+func optionalTimeQuery(c *fiber.Ctx, key string) (value time.Time, ok bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// characterPurgeInterval est la période de la purge des personnages supprimés.
+const characterPurgeInterval = time.Hour
+
+// runCharacterPurge supprime définitivement les personnages dont le délai de grâce est écoulé,
+// jusqu'à l'annulation de ctx. Les noms ainsi libérés redeviennent disponibles.
+func (s *Server) runCharacterPurge(ctx context.Context) {
+	ticker := time.NewTicker(characterPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeDeletedCharacters(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) purgeDeletedCharacters(ctx context.Context) {
+	purged, err := s.characters.PurgeDeletedCharacters(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error().Err(err).Msg("Failed to purge deleted characters")
+		}
+		return
+	}
+	if purged > 0 {
+		s.logger.Info().Int64("count", purged).Msg("Purged deleted characters")
+		s.appendAudit(ctx, &database.AuditEntry{
+			Action: database.AuditCharacterPurge,
+			After:  database.AuditSnapshot(map[string]int64{"count": purged}),
+		})
+	}
+}
+
+// grantExperienceHandler accorde de l'expérience à un personnage (support, événements).
+func (s *Server) grantExperienceHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	characterID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid character ID"})
+	}
+
+	var req struct {
+		Amount int64 `json:"amount"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Amount <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
+	}
+
+	before, err := s.characters.GetCharacterByID(c.Context(), characterID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Character not found"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to get character")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	character, err := s.characterHandler.GainExperience(c.Context(), characterID, req.Amount)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Character not found"})
+		}
+		s.logger.Error().Err(err).Int("character_id", characterID).Msg("Failed to grant experience")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	s.logger.Info().Int("character_id", characterID).Int64("amount", req.Amount).Int("granted_by", principal.UserID).Msg("Experience granted")
+	s.appendAudit(c.Context(), &database.AuditEntry{
+		ActorID:     &principal.UserID,
+		Action:      database.AuditCharacterXP,
+		UserID:      &character.UserID,
+		CharacterID: &characterID,
+		Before:      database.AuditSnapshot(fiber.Map{"level": before.Level, "experience": before.Experience}),
+		After:       database.AuditSnapshot(fiber.Map{"level": character.Level, "experience": character.Experience}),
+		IPAddress:   c.IP(),
+	})
+	return c.JSON(fiber.Map{"character": character})
+}
+
+// levelUpSpellHandler augmente d'un niveau un sort appris par un personnage (support, événements).
+func (s *Server) levelUpSpellHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	characterID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid character ID"})
+	}
+	spellID := c.Params("spellId")
+
+	character, err := s.characters.GetCharacterByID(c.Context(), characterID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Character not found"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to get character")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	spell, err := s.characterHandler.LevelUpSpell(c.Context(), characterID, spellID)
+	switch {
+	case errors.Is(err, database.ErrSpellNotKnown):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Spell not learned"})
+	case errors.Is(err, database.ErrSpellMaxLevel):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Spell already at max level"})
+	case err != nil:
+		s.logger.Error().Err(err).Int("character_id", characterID).Str("spell_id", spellID).Msg("Failed to level up spell")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	s.logger.Info().Int("character_id", characterID).Str("spell_id", spellID).Int("granted_by", principal.UserID).Msg("Spell leveled up")
+	s.appendAudit(c.Context(), &database.AuditEntry{
+		ActorID:     &principal.UserID,
+		Action:      database.AuditSpellLevelUp,
+		UserID:      &character.UserID,
+		CharacterID: &characterID,
+		Before:      database.AuditSnapshot(fiber.Map{"spell_id": spellID, "spell_level": spell.SpellLevel - 1}),
+		After:       database.AuditSnapshot(fiber.Map{"spell_id": spellID, "spell_level": spell.SpellLevel}),
+		IPAddress:   c.IP(),
+	})
+	return c.JSON(fiber.Map{"spell": spell})
+}
+
+// positionMetricsHandler retourne les métriques d'écriture différée des positions (latences en millisecondes).
+func (s *Server) positionMetricsHandler(c *fiber.Ctx) error {
+	stats := s.positions.Stats()
+	return c.JSON(fiber.Map{
+		"pending":          stats.Pending,
+		"flushes":          stats.Flushes,
+		"failedFlushes":    stats.FailedFlushes,
+		"flushedPositions": stats.FlushedPositions,
+		"droppedPositions": stats.DroppedPositions,
+		"lastFlushMs":      milliseconds(stats.LastFlushLatency),
+		"maxFlushMs":       milliseconds(stats.MaxFlushLatency),
+		"avgFlushMs":       milliseconds(stats.AvgFlushLatency),
+	})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MFACodeRequest struct {
+	Code string `json:"code"` // Code TOTP ou code de secours
+}
+
+type MFAVerifyRequest struct {
+	MFAToken   string `json:"mfaToken"`
+	Code       string `json:"code"`       // Code TOTP ou code de secours
+	DeviceName string `json:"deviceName"` // Optionnel, User-Agent par défaut
+}
+
+// mfaEnrollHandler génère un nouveau secret TOTP à confirmer avec mfaActivateHandler.
+func (s *Server) mfaEnrollHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+
+	mfa, err := s.db.GetUserMFA(c.Context(), principal.UserID)
+	if err != nil && !errors.Is(err, database.ErrMFANotFound) {
+		s.logger.Error().Err(err).Msg("Failed to get user MFA")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if mfa != nil && mfa.Enabled() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Two-factor authentication already enabled"})
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to generate TOTP secret")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if err := s.db.SavePendingMFA(c.Context(), principal.UserID, secret); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to save TOTP secret")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.JSON(fiber.Map{
+		"secret":     secret,
+		"otpauthUri": auth.TOTPURI(principal.Username, secret),
+	})
+}
+
+// mfaActivateHandler confirme l'enrôlement avec un premier code et renvoie les codes de secours (affichés une seule fois).
+func (s *Server) mfaActivateHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	req := new(MFACodeRequest)
+	if err := c.BodyParser(req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Code required"})
+	}
+
+	mfa, err := s.db.GetUserMFA(c.Context(), principal.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrMFANotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Two-factor enrollment not started"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to get user MFA")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if mfa.Enabled() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Two-factor authentication already enabled"})
+	}
+
+	step, ok := auth.ValidateTOTP(mfa.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid code"})
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to generate recovery codes")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if err := s.db.EnableMFA(c.Context(), principal.UserID, step, hashes); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to enable MFA")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	s.audit(c, principal.UserID, database.AuditMFAEnabled, principal.UserID, nil, nil)
+
+	return c.JSON(fiber.Map{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": codes,
+	})
+}
+
+// mfaDisableHandler désactive le 2FA après vérification d'un code.
+func (s *Server) mfaDisableHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	mfa, ok := s.requireSecondFactor(c, principal.UserID)
+	if !ok {
+		return nil
+	}
+
+	if err := s.db.DisableMFA(c.Context(), mfa.UserID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to disable MFA")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	s.audit(c, principal.UserID, database.AuditMFADisabled, principal.UserID, nil, nil)
+
+	return c.JSON(fiber.Map{"message": "Two-factor authentication disabled"})
+}
+
+// mfaRecoveryCodesHandler régénère les codes de secours après vérification d'un code.
+func (s *Server) mfaRecoveryCodesHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	mfa, ok := s.requireSecondFactor(c, principal.UserID)
+	if !ok {
+		return nil
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to generate recovery codes")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if err := s.db.ReplaceRecoveryCodes(c.Context(), mfa.UserID, hashes); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to replace recovery codes")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.JSON(fiber.Map{"recoveryCodes": codes})
+}
+
+// mfaVerifyHandler termine une connexion en deux étapes : le token "mfa_pending" reçu au login
+// et un code TOTP (ou de secours) sont échangés contre une vraie session.
+func (s *Server) mfaVerifyHandler(c *fiber.Ctx) error {
+	req := new(MFAVerifyRequest)
+	if err := c.BodyParser(req); err != nil || req.MFAToken == "" || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "MFA token and code required"})
+	}
+
+	claims, err := s.jwt.ValidateMFAToken(c.Context(), req.MFAToken)
+	if err != nil || claims.ID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired MFA token"})
+	}
+
+	// Les codes à 6 chiffres se devinent vite : même limitation que les mots de passe
+	ip := c.IP()
+	throttleKey := mfaThrottleKey(claims.UserID)
+	if wait := s.loginGuard.Check(ip, throttleKey); wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
+	// Le token "mfa_pending" est à usage unique : un token déjà échangé ne consomme pas de code de secours
+	consumed, err := s.db.IsTokenConsumed(c.Context(), claims.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to check MFA token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if consumed {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired MFA token"})
+	}
+
+	user, err := s.users.GetUserByID(c.Context(), claims.UserID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get user")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	mfa, err := s.db.GetUserMFA(c.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrMFANotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired MFA token"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to get user MFA")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	// Enrôlement non confirmé ou 2FA désactivé depuis l'émission du token
+	if !mfa.Enabled() {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired MFA token"})
+	}
+
+	valid, err := s.verifySecondFactor(c.Context(), mfa, req.Code)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to verify second factor")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if !valid {
+		return s.loginFailed(c, &user.ID, ip, throttleKey, database.LoginReasonBadOTP)
+	}
+
+	// Consommation atomique : de deux échanges concurrents du même token, un seul ouvre une session
+	ok, err := s.db.ConsumeToken(c.Context(), claims.ID, user.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to consume MFA token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired MFA token"})
+	}
+
+	return s.startSession(c, user, throttleKey, true, req.DeviceName)
+}
+
+// requireSecondFactor vérifie le code de la requête pour une opération sensible sur le 2FA.
+// Les échecs sont limités et journalisés comme ceux de mfaVerifyHandler (même compteur) : un access token
+// volé ne permet pas d'essayer tous les codes. En cas d'échec la réponse est déjà écrite et ok vaut false.
+func (s *Server) requireSecondFactor(c *fiber.Ctx, userID int) (mfa *database.UserMFA, ok bool) {
+	req := new(MFACodeRequest)
+	if err := c.BodyParser(req); err != nil || req.Code == "" {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Code required"})
+		return nil, false
+	}
+
+	ip := c.IP()
+	throttleKey := mfaThrottleKey(userID)
+	if wait := s.loginGuard.Check(ip, throttleKey); wait > 0 {
+		tooManyLoginAttempts(c, wait)
+		return nil, false
+	}
+
+	mfa, err := s.db.GetUserMFA(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, database.ErrMFANotFound) {
+			c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Two-factor authentication not enabled"})
+			return nil, false
+		}
+		s.logger.Error().Err(err).Msg("Failed to get user MFA")
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+		return nil, false
+	}
+	if !mfa.Enabled() {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Two-factor authentication not enabled"})
+		return nil, false
+	}
+
+	valid, err := s.verifySecondFactor(c.Context(), mfa, req.Code)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to verify second factor")
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+		return nil, false
+	}
+	if !valid {
+		locked := s.loginGuard.RecordFailure(ip, throttleKey)
+		reason := database.LoginReasonBadOTP
+		if locked {
+			reason = database.LoginReasonLocked
+			s.logger.Warn().Str("identifier", throttleKey).Str("ip", ip).Msg("Second factor locked after repeated failures")
+		}
+		s.recordLoginAttempt(c, &userID, throttleKey, false, reason)
+
+		if locked {
+			tooManyLoginAttempts(c, s.loginGuard.Check(ip, throttleKey))
+			return nil, false
+		}
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid code"})
+		return nil, false
+	}
+
+	s.loginGuard.RecordSuccess(throttleKey)
+	return mfa, true
+}
+
+// mfaThrottleKey est l'identifiant de limitation des codes de second facteur d'un compte.
+func mfaThrottleKey(userID int) string {
+	return "mfa:" + strconv.Itoa(userID)
+}
+
+// verifySecondFactor accepte un code TOTP (une seule fois par pas de temps) ou un code de secours inutilisé.
+func (s *Server) verifySecondFactor(ctx context.Context, mfa *database.UserMFA, code string) (bool, error) {
+	if step, ok := auth.ValidateTOTP(mfa.TOTPSecret, code, time.Now()); ok {
+		return s.db.UseTOTPStep(ctx, mfa.UserID, step)
+	}
+	return s.db.ConsumeRecoveryCode(ctx, mfa.UserID, auth.HashRecoveryCode(code))
+}
+
+// newRecoveryCodes génère les codes de secours et leurs empreintes à stocker.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	codes, err = auth.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes = make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/flumen/flumen_server/migrations"
+)
+
+// migrate applique les migrations en attente puis les données de référence nouvelles ou modifiées
+// (Config.AutoMigrate). Le verrou consultatif du Migrator sérialise les instances démarrées en même temps.
+func (s *Server) migrate(ctx context.Context) error {
+	migrator, err := database.NewMigrator(s.db, migrations.FS, s.logger)
+	if err != nil {
+		return fmt.Errorf("chargement des migrations: %w", err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("migrations: %w", err)
+	}
+	if err := migrator.Seed(ctx); err != nil {
+		return fmt.Errorf("données de référence: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxSanctionReasonLength = 500
+
+type SanctionRequest struct {
+	Reason      string `json:"reason"`
+	CharacterID *int   `json:"characterId"` // Absent : sanction sur tout le compte
+	Duration    string `json:"duration"`    // Durée ("72h", "30m"), vide pour une sanction permanente
+}
+
+// requireNotBanned refuse l'accès si le compte est banni. En cas de refus la réponse est déjà écrite.
+func (s *Server) requireNotBanned(c *fiber.Ctx, userID int) bool {
+	ban, err := s.db.GetActiveSanction(c.Context(), userID, nil, database.SanctionBan)
+	if errors.Is(err, database.ErrSanctionNotFound) {
+		return true
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get active ban")
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+		return false
+	}
+
+	c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error":     "Account banned",
+		"code":      "banned",
+		"reason":    ban.Reason,
+		"expiresAt": ban.ExpiresAt,
+	})
+	return false
+}
+
+// gameHandshakeHandler refuse l'ouverture du WebSocket de jeu aux comptes bannis et met à jour l'activité de la session.
+func (s *Server) gameHandshakeHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	if !s.requireNotBanned(c, principal.UserID) {
+		return nil
+	}
+
+	if err := s.db.TouchSession(c.Context(), principal.SessionID, c.IP()); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to update session activity")
+	}
+	return c.Next()
+}
+
+// getUserSanctionsHandler retourne l'historique des sanctions d'un utilisateur.
+func (s *Server) getUserSanctionsHandler(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	sanctions, err := s.db.GetUserSanctions(c.Context(), userID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get user sanctions")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	now := time.Now()
+	history := make([]fiber.Map, len(sanctions))
+	for i := range sanctions {
+		history[i] = sanctionJSON(&sanctions[i], now)
+	}
+	return c.JSON(fiber.Map{"userId": userID, "sanctions": history})
+}
+
+// banUserHandler bannit un compte ou un personnage, temporairement (suspension) ou définitivement.
+func (s *Server) banUserHandler(c *fiber.Ctx) error {
+	return s.issueSanction(c, database.SanctionBan)
+}
+
+// muteUserHandler interdit le chat à un compte ou un personnage.
+func (s *Server) muteUserHandler(c *fiber.Ctx) error {
+	return s.issueSanction(c, database.SanctionMute)
+}
+
+// liftBanHandler lève un bannissement avant son terme.
+func (s *Server) liftBanHandler(c *fiber.Ctx) error {
+	return s.liftSanction(c, database.SanctionBan)
+}
+
+// liftMuteHandler lève un mute avant son terme.
+func (s *Server) liftMuteHandler(c *fiber.Ctx) error {
+	return s.liftSanction(c, database.SanctionMute)
+}
+
+// issueSanction enregistre une sanction du type donné sur l'utilisateur de la route.
+func (s *Server) issueSanction(c *fiber.Ctx, sanctionType string) error {
+	principal, _ := auth.PrincipalFrom(c)
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	req := new(SanctionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" || len(reason) > maxSanctionReasonLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Reason must be between 1 and 500 characters"})
+	}
+
+	sanction := &database.Sanction{
+		UserID:      userID,
+		CharacterID: req.CharacterID,
+		Type:        sanctionType,
+		Reason:      reason,
+		IssuedBy:    &principal.UserID,
+	}
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid duration"})
+		}
+		expiresAt := time.Now().Add(duration)
+		sanction.ExpiresAt = &expiresAt
+	}
+
+	if _, err := s.users.GetUserByID(c.Context(), userID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to get user")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	if err := s.db.CreateSanction(c.Context(), sanction); err != nil {
+		if errors.Is(err, database.ErrCharacterNotOwned) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Character does not belong to user"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to create sanction")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	// Un bannissement du compte déconnecte immédiatement tous ses appareils
+	if sanctionType == database.SanctionBan && sanction.CharacterID == nil {
+		if err := s.db.RevokeAllUserSessions(c.Context(), userID); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to revoke user sessions")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+		}
+		s.connections.DisconnectUser(userID)
+	}
+
+	s.logger.Info().Int("user_id", userID).Str("type", sanctionType).Int("issued_by", principal.UserID).Msg("Sanction issued")
+	result := sanctionJSON(sanction, time.Now())
+	s.audit(c, principal.UserID, database.AuditSanctionIssued, userID, nil, result)
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// liftSanction lève la sanction de la route si elle est du type donné.
+func (s *Server) liftSanction(c *fiber.Ctx, sanctionType string) error {
+	principal, _ := auth.PrincipalFrom(c)
+	sanctionID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid sanction ID"})
+	}
+
+	sanction, err := s.db.RevokeSanction(c.Context(), int64(sanctionID), sanctionType, principal.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrSanctionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Sanction not found"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to revoke sanction")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	s.logger.Info().Int64("sanction_id", sanction.ID).Str("type", sanctionType).Int("revoked_by", principal.UserID).Msg("Sanction lifted")
+	result := sanctionJSON(sanction, time.Now())
+	s.audit(c, principal.UserID, database.AuditSanctionLifted, sanction.UserID, nil, result)
+	return c.JSON(result)
+}
+
+// sanctionJSON formate une sanction pour l'API d'administration.
+func sanctionJSON(sanction *database.Sanction, now time.Time) fiber.Map {
+	return fiber.Map{
+		"id":          sanction.ID,
+		"userId":      sanction.UserID,
+		"characterId": sanction.CharacterID,
+		"type":        sanction.Type,
+		"reason":      sanction.Reason,
+		"issuedBy":    sanction.IssuedBy,
+		"createdAt":   sanction.CreatedAt,
+		"expiresAt":   sanction.ExpiresAt,
+		"revokedAt":   sanction.RevokedAt,
+		"revokedBy":   sanction.RevokedBy,
+		"active":      sanction.Active(now),
+	}
+}
@@ -2,12 +2,18 @@ package server
 
 import (
 	"context"
-	"flumen_server/internal/auth"
-	"flumen_server/internal/database"
-	"flumen_server/internal/network"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/flumen/flumen_server/internal/models"
+	"github.com/flumen/flumen_server/internal/network"
 	"github.com/gofiber/fiber/v2"
-	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -22,17 +28,183 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Identifier string `json:"identifier"` // Peut être un email ou un username
 	Password   string `json:"password"`
+	DeviceName string `json:"deviceName"` // Optionnel, User-Agent par défaut
+}
+
+// dummyPasswordHash sert à comparer le mot de passe quand l'utilisateur n'existe pas,
+// pour que le temps de réponse ne révèle pas les identifiants valides.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("flumen-dummy-password"), bcrypt.DefaultCost)
+
+// shutdownTimeout borne l'arrêt du serveur et l'écriture des positions en attente.
+const shutdownTimeout = 15 * time.Second
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
 func (s *Server) Start(ctx context.Context, networkManager *network.Manager) error {
 	// ...
+	// Comptes utilisateurs : PostgreSQL sauf si un autre UserStore a été injecté (tests)
+	if s.users == nil {
+		s.users = s.db
+	}
+
+	// Schéma et données de référence embarqués dans le binaire, appliqués avant tout accès à la base
+	if s.config.AutoMigrate {
+		if err := s.migrate(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Registre des classes jouables, validé avant d'accepter des créations de personnages
+	classes, err := s.db.LoadClassRegistry(ctx)
+	if err != nil {
+		return fmt.Errorf("registre des classes: %w", err)
+	}
+	models.SetClassRegistry(classes)
+	s.logger.Info().Int("classes", classes.Len()).Msg("Character classes loaded")
+
+	// Personnages : positions écrites par lots, purge en arrière-plan des suppressions dont le délai de grâce est écoulé
+	if s.characters == nil {
+		s.characters = database.NewCharacterRepository(s.db, s.config.CharacterDeleteGracePeriod)
+	}
+	s.positions = database.NewPositionCache(s.characters)
+	go s.positions.Run(ctx, s.config.PositionFlushInterval, func(err error) {
+		s.logger.Error().Err(err).Msg("Failed to flush character positions")
+	})
+	go s.runCharacterPurge(ctx)
+
+	// Purge des révocations et refresh tokens expirés
+	go s.runTokenPurge(ctx)
+
+	// Arrêt propre quand ctx est annulé (main utilise signal.NotifyContext pour SIGINT/SIGTERM)
+	go s.shutdownOnCancel(ctx)
+
+	// Trousseau de clés JWT : fichier de rotation s'il est configuré, sinon le secret historique
+	keys, err := s.loadKeyRing()
+	if err != nil {
+		return err
+	}
+	s.jwt = auth.NewJWTService(keys, auth.JWTConfig{
+		AccessTTL:   s.config.JWTAccessTTL,
+		RefreshTTL:  s.config.JWTRefreshTTL,
+		Revocations: s.db,
+	})
+
+	// Protection contre le brute-force des connexions
+	s.loginGuard = auth.NewLoginGuard(auth.DefaultIPThrottle, auth.DefaultAccountThrottle)
+
+	// Tickets de connexion WebSocket à usage unique
+	s.wsTickets = auth.NewTicketStore(auth.WSTicketTTL)
+
+	// Connexions WebSocket de jeu indexées par session, fermées à la révocation de leur session
+	gameConnections := auth.NewConnectionRegistry()
+	gameConnections.OnUserDisconnected(s.gameUserDisconnected)
+	s.connections = gameConnections
+
+	// Envoi des emails (vérification, réinitialisation du mot de passe)
+	s.mailer = s.newMailer()
+
 	// Routes d'authentification
 	authGroup := s.app.Group("/auth")
 	authGroup.Post("/register", s.registerHandler)
 	authGroup.Post("/login", s.loginHandler)
+	authGroup.Post("/logout", s.jwt.Middleware(), s.logoutHandler)
+	authGroup.Post("/logout-all", s.jwt.Middleware(), s.logoutAllHandler)
+	authGroup.Post("/ws-ticket", s.jwt.Middleware(), s.wsTicketHandler)
+	authGroup.Post("/verify-email/request", s.jwt.Middleware(), s.verifyEmailRequestHandler)
+	authGroup.Post("/verify-email", s.verifyEmailHandler)
+	authGroup.Post("/password-reset/request", s.passwordResetRequestHandler)
+	authGroup.Post("/password-reset/confirm", s.passwordResetConfirmHandler)
+
+	// Authentification à deux facteurs (TOTP)
+	mfaGroup := authGroup.Group("/mfa")
+	mfaGroup.Post("/verify", s.mfaVerifyHandler)
+	mfaGroup.Post("/enroll", s.jwt.Middleware(), s.mfaEnrollHandler)
+	mfaGroup.Post("/activate", s.jwt.Middleware(), s.mfaActivateHandler)
+	mfaGroup.Post("/disable", s.jwt.Middleware(), s.mfaDisableHandler)
+	mfaGroup.Post("/recovery-codes", s.jwt.Middleware(), s.mfaRecoveryCodesHandler)
+
+	// Route de renouvellement des tokens
+	tokenGroup := s.app.Group("/token")
+	tokenGroup.Post("/refresh", s.refreshHandler)
+
+	// Routes de l'API protégées par le middleware JWT
+	api := s.app.Group("/api/v1", s.jwt.Middleware())
+	s.characterHandler.SetLogger(s.logger)
+	s.characterHandler.SetSanctionChecker(s.db)
+	s.characterHandler.SetAuditWriter(s.db)
+	s.characterHandler.SetPositionCache(s.positions)
+	switch characters := s.characters.(type) {
+	case *database.CharacterRepository:
+		s.characterHandler.SetSpellStore(database.NewSpellRepository(s.db))
+		s.characterHandler.SetTransactor(database.NewTxManager(s.db, characters))
+	case *database.MemoryStore:
+		s.characterHandler.SetSpellStore(characters)
+		s.characterHandler.SetTransactor(characters)
+	}
+	s.characterHandler.RegisterRoutes(api)
+
+	// Sessions actives (appareils connectés)
+	api.Get("/sessions", s.listSessionsHandler)
+	api.Delete("/sessions/:id", s.revokeSessionHandler)
 
-	// Route WebSocket pour le jeu
-	s.app.Get("/game", networkManager.HandleWebSocket) // ...
+	// Routes d'administration : permissions par rôle, 2FA exigé si configuré
+	admin := api.Group("/admin")
+	if s.config.AdminRequireMFA {
+		admin.Use(auth.RequireMFA())
+	}
+	admin.Get("/users/:id/roles", auth.RequirePermission(auth.PermViewUsers), s.getUserRolesHandler)
+	admin.Put("/users/:id/roles/:role", auth.RequirePermission(auth.PermManageRoles), s.grantRoleHandler)
+	admin.Delete("/users/:id/roles/:role", auth.RequirePermission(auth.PermManageRoles), s.revokeRoleHandler)
+	admin.Get("/users/:id/sanctions", auth.RequirePermission(auth.PermViewUsers), s.getUserSanctionsHandler)
+	admin.Post("/users/:id/bans", auth.RequirePermission(auth.PermBanUser), s.banUserHandler)
+	admin.Post("/users/:id/mutes", auth.RequirePermission(auth.PermMuteUser), s.muteUserHandler)
+	admin.Delete("/bans/:id", auth.RequirePermission(auth.PermBanUser), s.liftBanHandler)
+	admin.Delete("/mutes/:id", auth.RequirePermission(auth.PermMuteUser), s.liftMuteHandler)
+	admin.Post("/characters/:id/experience", auth.RequirePermission(auth.PermGrantXP), s.grantExperienceHandler)
+	admin.Post("/characters/:id/spells/:spellId/level-up", auth.RequirePermission(auth.PermGrantXP), s.levelUpSpellHandler)
+	admin.Get("/audit", auth.RequirePermission(auth.PermViewAudit), s.getAuditLogHandler)
+	admin.Get("/metrics/positions", auth.RequirePermission(auth.PermViewMetrics), s.positionMetricsHandler)
+
+	// Clés publiques JWT pour la vérification par les autres services
+	s.app.Get("/.well-known/jwks.json", s.jwksHandler)
+
+	// Route WebSocket pour le jeu : authentifiée par ticket (?ticket=), bannissements vérifiés à la poignée de main.
+	// Le network manager récupère l'utilisateur avec auth.PrincipalFrom et enregistre la connexion dans le
+	// registre des locals (auth.ConnectionsLocal) avec la session du Principal, jusqu'à sa fermeture.
+	s.app.Get("/game", s.wsTickets.Middleware(), s.gameHandshakeHandler, gameConnections.Middleware(), networkManager.HandleWebSocket) // ...
+}
+
+// Shutdown arrête le serveur HTTP puis écrit les positions des personnages encore en mémoire.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.app.ShutdownWithContext(ctx); err != nil {
+		return err
+	}
+	return s.positions.Close(ctx)
+}
+
+// shutdownOnCancel appelle Shutdown à l'annulation du contexte de Start.
+func (s *Server) shutdownOnCancel(ctx context.Context) {
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		s.logger.Error().Err(err).Msg("Graceful shutdown failed")
+		return
+	}
+	s.logger.Info().Msg("Server stopped, character positions flushed")
+}
+
+// gameUserDisconnected écrit la position du personnage joué quand la dernière connexion de jeu
+// de l'utilisateur se ferme (déconnexion du client ou révocation de sa session).
+func (s *Server) gameUserDisconnected(userID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.characterHandler.UserDisconnected(ctx, userID); err != nil {
+		s.logger.Error().Err(err).Int("user_id", userID).Msg("Failed to flush character position on disconnect")
+	}
 }
 
 // registerHandler gère l'inscription d'un nouvel utilisateur.
@@ -42,7 +214,10 @@ func (s *Server) registerHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
 	}
 
-	// TODO: Ajouter une validation plus robuste pour les entrées
+	normalizeRegisterRequest(req)
+	if errs := validateRegisterRequest(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "fields": errs})
+	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -57,12 +232,33 @@ func (s *Server) registerHandler(c *fiber.Ctx) error {
 		CharacterClass: req.CharacterClass,
 	}
 
-	if err := s.db.CreateUser(c.Context(), newUser); err != nil {
+	if err := s.users.CreateUser(c.Context(), newUser); err != nil {
+		switch {
+		case errors.Is(err, database.ErrUsernameTaken):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":  "Username already taken",
+				"fields": []FieldError{{"username", CodeUsernameTaken, "Username already taken"}},
+			})
+		case errors.Is(err, database.ErrEmailTaken):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":  "Email already taken",
+				"fields": []FieldError{{"email", CodeEmailTaken, "Email already taken"}},
+			})
+		}
 		s.logger.Error().Err(err).Msg("Failed to create user")
-		// TODO: Gérer les erreurs de duplications (username/email)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create user"})
 	}
 
+	s.audit(c, newUser.ID, database.AuditUserRegister, newUser.ID, nil, fiber.Map{
+		"username": newUser.Username,
+		"email":    newUser.Email,
+	})
+
+	// L'échec de l'envoi n'annule pas l'inscription : le joueur peut redemander un email
+	if err := s.sendVerificationEmail(c.Context(), newUser); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to issue verification token")
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "User created successfully"})
 }
 
@@ -73,15 +269,25 @@ func (s *Server) loginHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
 	}
 
+	// Limiter les tentatives par IP et par identifiant avant toute comparaison bcrypt
+	ip := c.IP()
+	identifier := strings.ToLower(strings.TrimSpace(req.Identifier))
+	if wait := s.loginGuard.Check(ip, identifier); wait > 0 {
+		s.recordLoginAttempt(c, nil, identifier, false, database.LoginReasonThrottled)
+		return tooManyLoginAttempts(c, wait)
+	}
+
 	// Déterminer si l'identifiant est un email ou un username
-	user, err := s.db.GetUserByEmail(c.Context(), req.Identifier)
-	if err == pgx.ErrNoRows {
-		user, err = s.db.GetUserByUsername(c.Context(), req.Identifier)
+	user, err := s.users.GetUserByEmail(c.Context(), identifier)
+	if errors.Is(err, database.ErrNotFound) {
+		user, err = s.users.GetUserByUsername(c.Context(), identifier)
 	}
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+		if errors.Is(err, database.ErrNotFound) {
+			// Comparaison factice pour un temps de réponse identique à un mauvais mot de passe
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+			return s.loginFailed(c, nil, ip, identifier, database.LoginReasonUnknownUser)
 		}
 		s.logger.Error().Err(err).Msg("Failed to get user")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
@@ -89,19 +295,100 @@ func (s *Server) loginHandler(c *fiber.Ctx) error {
 
 	// Comparer le mot de passe
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+		return s.loginFailed(c, &user.ID, ip, identifier, database.LoginReasonBadPassword)
+	}
+
+	// Second facteur : le mot de passe seul ne suffit pas si le 2FA est activé.
+	// Les compteurs d'échecs ne sont remis à zéro qu'une fois tous les facteurs vérifiés.
+	mfa, err := s.db.GetUserMFA(c.Context(), user.ID)
+	if err != nil && !errors.Is(err, database.ErrMFANotFound) {
+		s.logger.Error().Err(err).Msg("Failed to get user MFA")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	if mfa != nil && mfa.Enabled() {
+		mfaToken, err := s.jwt.GenerateMFAToken(user.ID)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to generate MFA token")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+		}
+		return c.JSON(fiber.Map{
+			"mfaRequired": true,
+			"mfaToken":    mfaToken,
+		})
 	}
 
-	// Générer les tokens
-	accessToken, refreshToken, err := auth.GenerateTokens(user.ID, user.Username, s.config.JWTSecret)
+	return s.startSession(c, user, identifier, false, req.DeviceName)
+}
+
+// loginSucceeded remet à zéro les compteurs d'échecs du compte (quel que soit l'identifiant utilisé)
+// et journalise la connexion réussie. À n'appeler qu'une fois tous les facteurs vérifiés.
+func (s *Server) loginSucceeded(c *fiber.Ctx, user *database.User, identifier string) {
+	for _, id := range []string{identifier, strings.ToLower(user.Email), strings.ToLower(user.Username)} {
+		s.loginGuard.RecordSuccess(id)
+	}
+	s.recordLoginAttempt(c, &user.ID, identifier, true, "")
+}
+
+// startSession crée une nouvelle session pour l'utilisateur authentifié et renvoie ses tokens.
+// identifier est la clé de limitation utilisée pour la tentative, remise à zéro si le compte n'est pas banni.
+func (s *Server) startSession(c *fiber.Ctx, user *database.User, identifier string, mfa bool, device string) error {
+	// Un compte banni ne peut ouvrir aucune session (login et vérification 2FA)
+	// et ne remet pas ses compteurs d'échecs à zéro
+	if !s.requireNotBanned(c, user.ID) {
+		return nil
+	}
+	s.loginSucceeded(c, user, identifier)
+
+	// Générer les tokens (une nouvelle session par connexion)
+	sessionID, err := auth.NewTokenID()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to generate session ID")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	roles, err := s.db.GetUserRoles(c.Context(), user.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get user roles")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	identity := auth.Identity{UserID: user.ID, Username: user.Username, Roles: roles, MFA: mfa}
+	tokens, err := s.jwt.GenerateTokens(identity, sessionID)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Failed to generate tokens")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
 	}
 
+	session := &database.Session{
+		ID:         sessionID,
+		UserID:     user.ID,
+		DeviceName: deviceName(c, device),
+		IPAddress:  c.IP(),
+	}
+	if err := s.db.CreateSession(c.Context(), session); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	if err := s.db.CreateRefreshToken(c.Context(), &database.RefreshToken{
+		ID:        tokens.RefreshTokenID,
+		UserID:    user.ID,
+		SessionID: tokens.SessionID,
+		ExpiresAt: tokens.RefreshExpiresAt,
+	}); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to store refresh token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	s.audit(c, user.ID, database.AuditUserLogin, user.ID, nil, fiber.Map{
+		"sessionId":  session.ID,
+		"deviceName": session.DeviceName,
+		"mfa":        mfa,
+	})
+
 	return c.JSON(fiber.Map{
-		"accessToken":  accessToken,
-		"refreshToken": refreshToken,
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
 		"user": fiber.Map{
 			"id":       user.ID,
 			"username": user.Username,
@@ -109,6 +396,187 @@ func (s *Server) loginHandler(c *fiber.Ctx) error {
 	})
 }
 
+// loginFailed enregistre l'échec, applique le backoff et renvoie 401 (ou 429 si l'identifiant vient d'être verrouillé).
+func (s *Server) loginFailed(c *fiber.Ctx, userID *int, ip, identifier, reason string) error {
+	locked := s.loginGuard.RecordFailure(ip, identifier)
+	if locked {
+		reason = database.LoginReasonLocked
+		s.logger.Warn().Str("identifier", identifier).Str("ip", ip).Msg("Login locked after repeated failures")
+	}
+	s.recordLoginAttempt(c, userID, identifier, false, reason)
+
+	if locked {
+		return tooManyLoginAttempts(c, s.loginGuard.Check(ip, identifier))
+	}
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+}
+
+// recordLoginAttempt ajoute la tentative au journal. Une erreur d'écriture n'empêche pas la connexion.
+func (s *Server) recordLoginAttempt(c *fiber.Ctx, userID *int, identifier string, success bool, reason string) {
+	attempt := &database.LoginAttempt{
+		UserID:     userID,
+		Identifier: identifier,
+		IPAddress:  c.IP(),
+		Success:    success,
+		Reason:     reason,
+	}
+	if err := s.db.RecordLoginAttempt(c.Context(), attempt); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to record login attempt")
+	}
+}
+
+// tooManyLoginAttempts renvoie 429 avec l'en-tête Retry-After.
+func tooManyLoginAttempts(c *fiber.Ctx, wait time.Duration) error {
+	return tooManyRequests(c, wait, "Too many login attempts")
+}
+
+// tooManyRequests renvoie 429 avec le délai d'attente en secondes (en-tête Retry-After et corps).
+func tooManyRequests(c *fiber.Ctx, wait time.Duration, message string) error {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":      message,
+		"retryAfter": retryAfter,
+	})
+}
+
+// refreshHandler échange un refresh token valide contre une nouvelle paire de tokens.
+// Le refresh token présenté est consommé : le réutiliser révoque toute la session.
+func (s *Server) refreshHandler(c *fiber.Ctx) error {
+	req := new(RefreshRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+	if req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Refresh token required"})
+	}
+
+	claims, err := s.jwt.ValidateRefreshToken(c.Context(), req.RefreshToken)
+	if err != nil || claims.ID == "" || claims.SessionID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+	}
+
+	user, err := s.users.GetUserByID(c.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to get user")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	if !s.requireNotBanned(c, user.ID) {
+		return nil
+	}
+
+	// Les rôles sont rechargés à chaque refresh pour refléter les changements de droits
+	roles, err := s.db.GetUserRoles(c.Context(), user.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get user roles")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	identity := auth.Identity{UserID: user.ID, Username: user.Username, Roles: roles, MFA: claims.HasMFA()}
+	tokens, err := s.jwt.GenerateTokens(identity, claims.SessionID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to generate tokens")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	err = s.db.RotateRefreshToken(c.Context(), claims.ID, &database.RefreshToken{
+		ID:        tokens.RefreshTokenID,
+		UserID:    user.ID,
+		SessionID: tokens.SessionID,
+		ExpiresAt: tokens.RefreshExpiresAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrRefreshTokenReused):
+			s.logger.Warn().Int("user_id", user.ID).Str("session_id", claims.SessionID).Msg("Refresh token reuse detected, session revoked")
+			s.connections.DisconnectSession(claims.SessionID)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+		case errors.Is(err, database.ErrRefreshTokenInvalid):
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to rotate refresh token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	if err := s.db.TouchSession(c.Context(), claims.SessionID, c.IP()); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to update session activity")
+	}
+
+	return c.JSON(fiber.Map{
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+	})
+}
+
+// logoutHandler révoque la session courante et tous ses refresh tokens.
+func (s *Server) logoutHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+
+	if err := s.db.RevokeSession(c.Context(), principal.SessionID, principal.UserID); err != nil {
+		if errors.Is(err, database.ErrSessionNotFound) {
+			return auth.Unauthorized(c, "invalid_token", "Invalid token")
+		}
+		s.logger.Error().Err(err).Msg("Failed to revoke session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	// L'access token présenté est aussi révoqué par son jti, jusqu'à son expiration
+	if err := s.db.RevokeToken(c.Context(), principal.TokenID, principal.UserID, principal.ExpiresAt); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to revoke access token")
+	}
+	s.connections.DisconnectSession(principal.SessionID)
+	s.audit(c, principal.UserID, database.AuditUserLogout, principal.UserID, fiber.Map{"sessionId": principal.SessionID}, nil)
+
+	return c.JSON(fiber.Map{"message": "Logged out"})
+}
+
+// logoutAllHandler révoque toutes les sessions de l'utilisateur (déconnexion de tous les appareils).
+func (s *Server) logoutAllHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+
+	if err := s.db.RevokeAllUserSessions(c.Context(), principal.UserID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to revoke user sessions")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	s.connections.DisconnectUser(principal.UserID)
+	s.audit(c, principal.UserID, database.AuditUserLogoutAll, principal.UserID, nil, nil)
+
+	return c.JSON(fiber.Map{"message": "Logged out from all devices"})
+}
+
+// wsTicketHandler échange l'access token contre un ticket de connexion WebSocket (30 secondes, usage unique,
+// lié à l'IP), pour que l'access token n'apparaisse ni dans l'URL du WebSocket ni dans les logs des proxys.
+func (s *Server) wsTicketHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+
+	ticket, expiresAt, err := s.wsTickets.Issue(principal, c.IP())
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to issue WebSocket ticket")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.JSON(fiber.Map{
+		"ticket":    ticket,
+		"expiresAt": expiresAt,
+	})
+}
+
+// jwksHandler publie les clés publiques (EdDSA/RS256) du trousseau au format JWKS.
+func (s *Server) jwksHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"keys": s.jwt.Keys().JWKS()})
+}
+
+// loadKeyRing construit le trousseau de clés JWT à partir de la configuration.
+func (s *Server) loadKeyRing() (*auth.KeyRing, error) {
+	if s.config.JWTKeysFile != "" {
+		return auth.LoadKeyRing(s.config.JWTKeysFile)
+	}
+	return auth.KeyRingFromSecret(s.config.JWTSecret)
+}
+
 // Supprimer les handlers de démo
 // func (s *Server) demoLoginHandler(c *fiber.Ctx) error ...
 // func (s *Server) demoRegisterHandler(c *fiber.Ctx) error ...
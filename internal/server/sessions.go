@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/flumen/flumen_server/internal/auth"
+	"github.com/flumen/flumen_server/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxDeviceNameLength = 100
+
+// tokenPurgeInterval est la période de la purge des tokens expirés.
+const tokenPurgeInterval = time.Hour
+
+// gameConnections donne accès aux connexions WebSocket de jeu ouvertes, indexées par session.
+// Implémentée par auth.ConnectionRegistry.
+type gameConnections interface {
+	IsSessionConnected(sessionID string) bool
+	DisconnectSession(sessionID string)
+	DisconnectUser(userID int)
+}
+
+// runTokenPurge supprime les entrées de revoked_tokens et les refresh tokens expirés, devenus inutiles,
+// jusqu'à l'annulation de ctx.
+func (s *Server) runTokenPurge(ctx context.Context) {
+	ticker := time.NewTicker(tokenPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.db.PurgeExpiredTokens(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error().Err(err).Msg("Failed to purge expired tokens")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deviceName retourne le nom d'appareil fourni par le client, ou à défaut son User-Agent.
+func deviceName(c *fiber.Ctx, requested string) string {
+	name := strings.TrimSpace(requested)
+	if name == "" {
+		name = strings.TrimSpace(c.Get(fiber.HeaderUserAgent))
+	}
+	if utf8.RuneCountInString(name) > maxDeviceNameLength {
+		name = string([]rune(name)[:maxDeviceNameLength])
+	}
+	return name
+}
+
+// listSessionsHandler liste les sessions actives de l'utilisateur et indique celles connectées au jeu.
+func (s *Server) listSessionsHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+
+	sessions, err := s.db.GetActiveSessions(c.Context(), principal.UserID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get active sessions")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	result := make([]fiber.Map, len(sessions))
+	for i, session := range sessions {
+		result[i] = fiber.Map{
+			"id":         session.ID,
+			"deviceName": session.DeviceName,
+			"ipAddress":  session.IPAddress,
+			"createdAt":  session.CreatedAt,
+			"lastSeenAt": session.LastSeenAt,
+			"connected":  s.connections.IsSessionConnected(session.ID),
+			"current":    session.ID == principal.SessionID,
+		}
+	}
+	return c.JSON(fiber.Map{"sessions": result})
+}
+
+// revokeSessionHandler révoque une session de l'utilisateur et ferme son WebSocket de jeu.
+func (s *Server) revokeSessionHandler(c *fiber.Ctx) error {
+	principal, _ := auth.PrincipalFrom(c)
+	sessionID := c.Params("id")
+
+	if err := s.db.RevokeSession(c.Context(), sessionID, principal.UserID); err != nil {
+		if errors.Is(err, database.ErrSessionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
+		}
+		s.logger.Error().Err(err).Msg("Failed to revoke session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	s.connections.DisconnectSession(sessionID)
+	s.audit(c, principal.UserID, database.AuditSessionRevoked, principal.UserID, fiber.Map{"sessionId": sessionID}, nil)
+
+	return c.JSON(fiber.Map{"message": "Session revoked"})
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/flumen/flumen_server/internal/models"
+)
+
+// Codes d'erreur par champ renvoyés au client lors de l'inscription.
+const (
+	CodeRequired      = "required"
+	CodeInvalidLength = "invalid_length"
+	CodeInvalidFormat = "invalid_format"
+	CodeWeakPassword  = "weak_password"
+	CodeInvalidClass  = "invalid_class"
+	CodeUsernameTaken = "username_taken"
+	CodeEmailTaken    = "email_taken"
+)
+
+const (
+	minUsernameLength  = 3
+	maxUsernameLength  = 20
+	maxEmailLength     = 254
+	minPasswordLength  = 8
+	maxPasswordLength  = 72 // Limite de bcrypt
+	passwordCharGroups = 3  // Parmi minuscules, majuscules, chiffres, symboles
+)
+
+// usernamePattern : lettre initiale puis lettres, chiffres, '_' ou '-'.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// FieldError décrit une erreur de validation sur un champ.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// normalizeRegisterRequest nettoie les champs avant validation.
+// L'email est stocké en minuscules ; la casse du username est conservée pour l'affichage
+// mais son unicité est insensible à la casse (index LOWER(username)).
+func normalizeRegisterRequest(req *RegisterRequest) {
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	req.CharacterClass = strings.ToLower(strings.TrimSpace(req.CharacterClass))
+}
+
+// validateRegisterRequest valide une demande d'inscription déjà normalisée.
+func validateRegisterRequest(req *RegisterRequest) []FieldError {
+	var errs []FieldError
+
+	switch {
+	case req.Username == "":
+		errs = append(errs, FieldError{"username", CodeRequired, "Username is required"})
+	case len(req.Username) < minUsernameLength || len(req.Username) > maxUsernameLength:
+		errs = append(errs, FieldError{"username", CodeInvalidLength, "Username must be between 3 and 20 characters"})
+	case !usernamePattern.MatchString(req.Username):
+		errs = append(errs, FieldError{"username", CodeInvalidFormat, "Username must start with a letter and contain only letters, digits, '_' or '-'"})
+	}
+
+	switch {
+	case req.Email == "":
+		errs = append(errs, FieldError{"email", CodeRequired, "Email is required"})
+	case len(req.Email) > maxEmailLength:
+		errs = append(errs, FieldError{"email", CodeInvalidLength, "Email is too long"})
+	case !isValidEmail(req.Email):
+		errs = append(errs, FieldError{"email", CodeInvalidFormat, "Email is invalid"})
+	}
+
+	switch {
+	case req.Password == "":
+		errs = append(errs, FieldError{"password", CodeRequired, "Password is required"})
+	case len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength:
+		errs = append(errs, FieldError{"password", CodeInvalidLength, "Password must be between 8 and 72 characters"})
+	case !isStrongPassword(req.Password, req.Username):
+		errs = append(errs, FieldError{"password", CodeWeakPassword, "Password must mix at least 3 of lowercase, uppercase, digits and symbols and must not contain the username"})
+	}
+
+	if models.GetClassInfo(models.CharacterClass(req.CharacterClass)).ID == "" {
+		errs = append(errs, FieldError{"characterClass", CodeInvalidClass, "Character class is invalid"})
+	}
+
+	return errs
+}
+
+// isValidEmail vérifie que l'email est une adresse simple (sans nom affiché) avec un domaine.
+func isValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	return at > 0 && strings.Contains(email[at+1:], ".")
+}
+
+// isStrongPassword applique la politique de mot de passe.
+func isStrongPassword(password, username string) bool {
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return false
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	groups := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			groups++
+		}
+	}
+	return groups >= passwordCharGroups
+}
@@ -0,0 +1,4 @@
+-- Migration pour supprimer la table users
+DROP TRIGGER IF EXISTS trigger_users_updated_at ON users;
+DROP FUNCTION IF EXISTS update_users_updated_at();
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,31 @@
+-- Migration pour créer la table users
+-- IF NOT EXISTS : les bases créées avant cette migration ont déjà la table
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50) NOT NULL UNIQUE,
+    email VARCHAR(254) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    character_class VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Trigger pour mettre à jour automatiquement updated_at
+CREATE OR REPLACE FUNCTION update_users_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_users_updated_at ON users;
+CREATE TRIGGER trigger_users_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION update_users_updated_at();
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE users IS 'Comptes des joueurs';
+COMMENT ON COLUMN users.password_hash IS 'Empreinte bcrypt du mot de passe';
+COMMENT ON COLUMN users.character_class IS 'Classe choisie à l''inscription';
@@ -0,0 +1,2 @@
+-- Migration pour supprimer la table refresh_tokens
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,22 @@
+-- Migration pour créer la table refresh_tokens (rotation à usage unique)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Index pour optimiser les requêtes
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE refresh_tokens IS 'Refresh tokens émis, consommés une seule fois lors de la rotation';
+COMMENT ON COLUMN refresh_tokens.id IS 'jti du refresh token';
+COMMENT ON COLUMN refresh_tokens.session_id IS 'Famille de tokens issue d''une même connexion';
+COMMENT ON COLUMN refresh_tokens.used_at IS 'Date de consommation (rotation), NULL si encore valide';
+COMMENT ON COLUMN refresh_tokens.revoked_at IS 'Date de révocation (réutilisation détectée), NULL sinon';
@@ -0,0 +1,4 @@
+-- Migration pour supprimer les tables de sessions et de révocation
+ALTER TABLE refresh_tokens DROP CONSTRAINT IF EXISTS fk_refresh_tokens_session;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS user_sessions;
@@ -0,0 +1,34 @@
+-- Migration pour créer les tables de sessions et de révocation des tokens
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Reprendre les sessions des refresh tokens déjà émis
+INSERT INTO user_sessions (id, user_id, created_at)
+SELECT session_id, MIN(user_id), MIN(created_at)
+FROM refresh_tokens
+GROUP BY session_id
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT fk_refresh_tokens_session
+    FOREIGN KEY (session_id) REFERENCES user_sessions(id) ON DELETE CASCADE;
+
+-- Index pour optimiser les requêtes
+CREATE INDEX idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE user_sessions IS 'Sessions de connexion (une par login), révocables';
+COMMENT ON TABLE revoked_tokens IS 'Liste de révocation des tokens par jti, purgeable après expiration';
+COMMENT ON COLUMN user_sessions.revoked_at IS 'Date de révocation (logout, réutilisation détectée), NULL si active';
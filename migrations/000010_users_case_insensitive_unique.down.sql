@@ -0,0 +1,3 @@
+-- Migration pour supprimer les index d'unicité insensibles à la casse
+DROP INDEX IF EXISTS idx_users_email_lower;
+DROP INDEX IF EXISTS idx_users_username_lower;
@@ -0,0 +1,38 @@
+-- Migration pour rendre l'unicité des usernames et emails insensible à la casse
+-- Des emails identiques à la casse près désignent la même boîte : fusionner ces comptes ne peut pas
+-- se faire automatiquement, la migration échoue en les listant pour qu'ils soient traités à la main.
+DO $$
+DECLARE
+    conflicts TEXT;
+BEGIN
+    SELECT string_agg(format('%s (ids %s)', email, ids), '; ' ORDER BY email)
+    INTO conflicts
+    FROM (
+        SELECT LOWER(TRIM(email)) AS email, string_agg(id::TEXT, ', ' ORDER BY id) AS ids
+        FROM users
+        GROUP BY LOWER(TRIM(email))
+        HAVING COUNT(*) > 1
+    ) duplicates;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'emails en double à la casse près, à fusionner ou corriger avant la migration: %', conflicts;
+    END IF;
+END $$;
+
+UPDATE users SET email = LOWER(TRIM(email));
+
+-- Les usernames en double (à la casse près) restent au compte le plus ancien ;
+-- les suivants sont renommés avec leur ID et devront être changés par leur joueur.
+UPDATE users u
+SET username = LEFT(u.username, 20 - LENGTH('_' || u.id)) || '_' || u.id
+WHERE EXISTS (
+    SELECT 1 FROM users older
+    WHERE LOWER(older.username) = LOWER(u.username) AND older.id < u.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email));
+
+-- Commentaires pour la documentation
+COMMENT ON INDEX idx_users_username_lower IS 'Unicité du username insensible à la casse';
+COMMENT ON INDEX idx_users_email_lower IS 'Unicité de l''email insensible à la casse (stocké en minuscules)';
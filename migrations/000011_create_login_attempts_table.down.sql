@@ -0,0 +1,2 @@
+-- Migration pour supprimer la table login_attempts
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,20 @@
+-- Migration pour créer la table login_attempts (journal des tentatives de connexion)
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    identifier VARCHAR(254) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    success BOOLEAN NOT NULL,
+    reason VARCHAR(32),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Index pour optimiser les requêtes
+CREATE INDEX idx_login_attempts_user_id ON login_attempts(user_id, created_at DESC);
+CREATE INDEX idx_login_attempts_ip_address ON login_attempts(ip_address, created_at DESC);
+CREATE INDEX idx_login_attempts_created_at ON login_attempts(created_at);
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE login_attempts IS 'Journal des tentatives de connexion (réussies et échouées)';
+COMMENT ON COLUMN login_attempts.user_id IS 'Utilisateur ciblé, NULL si l''identifiant est inconnu';
+COMMENT ON COLUMN login_attempts.reason IS 'Cause de l''échec: unknown_user, bad_password, throttled, locked';
@@ -0,0 +1,3 @@
+-- Migration pour supprimer les tokens email
+DROP TABLE IF EXISTS user_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,21 @@
+-- Migration pour la vérification des emails et la réinitialisation des mots de passe
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL CHECK (purpose IN ('email_verification', 'password_reset')),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Index pour optimiser les requêtes
+CREATE INDEX idx_user_tokens_user_purpose ON user_tokens(user_id, purpose);
+CREATE INDEX idx_user_tokens_expires_at ON user_tokens(expires_at);
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE user_tokens IS 'Tokens à usage unique envoyés par email';
+COMMENT ON COLUMN user_tokens.token_hash IS 'Empreinte SHA-256 du token (le token lui-même n''est jamais stocké)';
+COMMENT ON COLUMN users.email_verified_at IS 'Date de vérification de l''email, NULL si non vérifié';
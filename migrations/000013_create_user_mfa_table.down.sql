@@ -0,0 +1,3 @@
+-- Migration pour supprimer l'authentification à deux facteurs
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
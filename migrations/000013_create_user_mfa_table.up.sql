@@ -0,0 +1,21 @@
+-- Migration pour l'authentification à deux facteurs (TOTP)
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    totp_secret VARCHAR(64) NOT NULL,
+    enabled_at TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (user_id, code_hash)
+);
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE user_mfa IS 'Secret TOTP par utilisateur; enabled_at NULL tant que l''enrôlement n''est pas confirmé';
+COMMENT ON COLUMN user_mfa.last_used_step IS 'Dernier pas de temps TOTP accepté, pour refuser le rejeu d''un code';
+COMMENT ON TABLE user_recovery_codes IS 'Codes de secours à usage unique (empreintes SHA-256)';
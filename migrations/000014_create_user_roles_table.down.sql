@@ -0,0 +1,2 @@
+-- Migration pour supprimer la table user_roles
+DROP TABLE IF EXISTS user_roles;
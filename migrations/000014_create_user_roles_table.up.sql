@@ -0,0 +1,12 @@
+-- Migration pour créer la table user_roles (contrôle d'accès par rôle)
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(32) NOT NULL CHECK (role IN ('moderator', 'admin')),
+    granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    granted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE user_roles IS 'Rôles attribués aux utilisateurs (le rôle player est implicite)';
+COMMENT ON COLUMN user_roles.granted_by IS 'Administrateur ayant attribué le rôle, NULL si attribué hors jeu';
@@ -0,0 +1,2 @@
+-- Migration pour supprimer la table sanctions
+DROP TABLE IF EXISTS sanctions;
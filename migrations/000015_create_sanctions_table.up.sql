@@ -0,0 +1,26 @@
+-- Migration pour créer la table sanctions (bannissements, suspensions, mutes)
+CREATE TABLE IF NOT EXISTS sanctions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    character_id INTEGER REFERENCES characters(id) ON DELETE CASCADE,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('ban', 'mute')),
+    reason TEXT NOT NULL,
+    issued_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (expires_at IS NULL OR expires_at > created_at)
+);
+
+-- Index pour optimiser les requêtes
+CREATE INDEX idx_sanctions_user_id ON sanctions(user_id, created_at DESC);
+CREATE INDEX idx_sanctions_active ON sanctions(user_id, type) WHERE revoked_at IS NULL;
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE sanctions IS 'Historique des sanctions (jamais supprimées, seulement levées)';
+COMMENT ON COLUMN sanctions.character_id IS 'Personnage sanctionné, NULL si la sanction porte sur tout le compte';
+COMMENT ON COLUMN sanctions.type IS 'Type de sanction: ban (connexion refusée), mute (chat interdit)';
+COMMENT ON COLUMN sanctions.issued_by IS 'Modérateur ayant prononcé la sanction';
+COMMENT ON COLUMN sanctions.expires_at IS 'Fin de la sanction, NULL si permanente';
+COMMENT ON COLUMN sanctions.revoked_at IS 'Date de levée anticipée de la sanction';
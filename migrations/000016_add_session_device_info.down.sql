@@ -0,0 +1,5 @@
+-- Migration pour retirer les informations d'appareil des sessions
+DROP INDEX IF EXISTS idx_user_sessions_active;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS last_seen_at;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS device_name;
@@ -0,0 +1,16 @@
+-- Migration pour ajouter les informations d'appareil aux sessions
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS device_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE user_sessions SET last_seen_at = created_at WHERE last_seen_at IS NULL;
+ALTER TABLE user_sessions ALTER COLUMN last_seen_at SET DEFAULT NOW();
+ALTER TABLE user_sessions ALTER COLUMN last_seen_at SET NOT NULL;
+
+-- Index pour optimiser les requêtes
+CREATE INDEX idx_user_sessions_active ON user_sessions(user_id, last_seen_at DESC) WHERE revoked_at IS NULL;
+
+-- Commentaires pour la documentation
+COMMENT ON COLUMN user_sessions.device_name IS 'Nom de l''appareil fourni par le client, ou son User-Agent';
+COMMENT ON COLUMN user_sessions.ip_address IS 'Dernière adresse IP connue de la session';
+COMMENT ON COLUMN user_sessions.last_seen_at IS 'Dernière activité (refresh des tokens, connexion WebSocket)';
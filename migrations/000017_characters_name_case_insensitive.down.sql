@@ -0,0 +1,4 @@
+-- Migration pour revenir à l'unicité des noms de personnages sensible à la casse
+DROP INDEX IF EXISTS idx_characters_name_lower;
+CREATE INDEX IF NOT EXISTS idx_characters_name ON characters(name);
+ALTER TABLE characters ADD CONSTRAINT characters_name_key UNIQUE (name);
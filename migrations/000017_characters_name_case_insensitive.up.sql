@@ -0,0 +1,16 @@
+-- Migration pour rendre l'unicité des noms de personnages insensible à la casse
+-- Les doublons existants (même nom à la casse près) gardent leur nom pour le plus ancien ;
+-- les suivants sont renommés avec leur ID et devront être renommés par leur joueur.
+UPDATE characters c
+SET name = LEFT(c.name, 20 - LENGTH('_' || c.id)) || '_' || c.id
+WHERE EXISTS (
+    SELECT 1 FROM characters older
+    WHERE LOWER(older.name) = LOWER(c.name) AND older.id < c.id
+);
+
+ALTER TABLE characters DROP CONSTRAINT IF EXISTS characters_name_key;
+DROP INDEX IF EXISTS idx_characters_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_characters_name_lower ON characters (LOWER(name));
+
+-- Commentaires pour la documentation
+COMMENT ON INDEX idx_characters_name_lower IS 'Unicité du nom de personnage insensible à la casse';
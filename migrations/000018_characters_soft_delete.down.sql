@@ -0,0 +1,4 @@
+-- Migration pour revenir à la suppression immédiate des personnages
+DELETE FROM characters WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_characters_deleted_at;
+ALTER TABLE characters DROP COLUMN IF EXISTS deleted_at;
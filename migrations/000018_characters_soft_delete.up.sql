@@ -0,0 +1,10 @@
+-- Migration pour la suppression différée des personnages
+-- Un personnage supprimé reste restaurable pendant le délai de grâce ; son nom reste réservé
+-- (l'index idx_characters_name_lower couvre aussi les personnages supprimés) jusqu'à la purge.
+ALTER TABLE characters ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Index pour optimiser les requêtes
+CREATE INDEX IF NOT EXISTS idx_characters_deleted_at ON characters(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Commentaires pour la documentation
+COMMENT ON COLUMN characters.deleted_at IS 'Date de suppression, NULL si actif ; purgé après le délai de grâce';
@@ -0,0 +1,4 @@
+-- Migration pour supprimer le journal d'audit
+DROP TRIGGER IF EXISTS trigger_audit_log_append_only ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,42 @@
+-- Migration pour créer le journal d'audit des comptes et des personnages
+-- Pas de clés étrangères : les entrées doivent survivre à la suppression des comptes et à la purge des personnages.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER,
+    action VARCHAR(64) NOT NULL,
+    user_id INTEGER,
+    character_id INTEGER,
+    before JSONB,
+    after JSONB,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Index pour optimiser les requêtes
+CREATE INDEX idx_audit_log_user_id ON audit_log(user_id, created_at DESC) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_audit_log_character_id ON audit_log(character_id, created_at DESC) WHERE character_id IS NOT NULL;
+CREATE INDEX idx_audit_log_action ON audit_log(action, created_at DESC);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at DESC);
+
+-- Trigger pour garantir un journal en ajout seul : modifications et suppressions refusées
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log est en ajout seul';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_audit_log_append_only ON audit_log;
+CREATE TRIGGER trigger_audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION audit_log_append_only();
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE audit_log IS 'Journal d''audit en ajout seul des actions sur les comptes et les personnages';
+COMMENT ON COLUMN audit_log.actor_id IS 'Utilisateur ayant effectué l''action, NULL pour le système';
+COMMENT ON COLUMN audit_log.action IS 'Action effectuée (user.login, character.create, ...)';
+COMMENT ON COLUMN audit_log.user_id IS 'Compte concerné par l''action';
+COMMENT ON COLUMN audit_log.character_id IS 'Personnage concerné, NULL si l''action porte sur le compte';
+COMMENT ON COLUMN audit_log.before IS 'État avant l''action';
+COMMENT ON COLUMN audit_log.after IS 'État après l''action';
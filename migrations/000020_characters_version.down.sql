@@ -0,0 +1,2 @@
+-- Migration pour supprimer le verrouillage optimiste des personnages
+ALTER TABLE characters DROP COLUMN IF EXISTS version;
@@ -0,0 +1,7 @@
+-- Migration pour le verrouillage optimiste des personnages
+-- version est incrémentée à chaque mise à jour des stats ou de l'expérience ; une écriture fondée
+-- sur une version périmée est refusée au lieu d'écraser une autre mise à jour.
+ALTER TABLE characters ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Commentaires pour la documentation
+COMMENT ON COLUMN characters.version IS 'Version des stats et de l''expérience, pour le verrouillage optimiste';
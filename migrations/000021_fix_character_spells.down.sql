@@ -0,0 +1,13 @@
+-- Migration pour revenir à l'ancien schéma de character_spells (les sorts appris sont perdus)
+DELETE FROM character_spells;
+
+ALTER TABLE character_spells DROP CONSTRAINT IF EXISTS character_spells_spell_level_check;
+ALTER TABLE character_spells DROP COLUMN IF EXISTS learned_at;
+ALTER TABLE character_spells ALTER COLUMN xp DROP NOT NULL;
+ALTER TABLE character_spells ALTER COLUMN xp DROP DEFAULT;
+ALTER TABLE character_spells ALTER COLUMN spell_level DROP NOT NULL;
+ALTER TABLE character_spells ALTER COLUMN spell_level DROP DEFAULT;
+ALTER TABLE character_spells DROP CONSTRAINT IF EXISTS character_spells_character_id_fkey;
+ALTER TABLE character_spells ALTER COLUMN character_id TYPE UUID USING NULL;
+
+CREATE INDEX IF NOT EXISTS idx_character_spell ON character_spells(character_id, spell_id);
@@ -0,0 +1,27 @@
+-- Migration pour aligner character_spells sur la table characters
+-- character_id était un UUID sans clé étrangère alors que characters.id est un entier : aucune ligne
+-- existante ne peut être rattachée à un personnage, elles sont donc supprimées.
+DELETE FROM character_spells;
+
+ALTER TABLE character_spells
+    ALTER COLUMN character_id TYPE INTEGER USING NULL;
+
+ALTER TABLE character_spells
+    ADD CONSTRAINT character_spells_character_id_fkey
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE;
+
+-- Niveau et expérience du sort toujours renseignés
+ALTER TABLE character_spells ALTER COLUMN spell_level SET DEFAULT 1;
+ALTER TABLE character_spells ALTER COLUMN spell_level SET NOT NULL;
+ALTER TABLE character_spells ALTER COLUMN xp SET DEFAULT 0;
+ALTER TABLE character_spells ALTER COLUMN xp SET NOT NULL;
+ALTER TABLE character_spells ADD COLUMN IF NOT EXISTS learned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+ALTER TABLE character_spells ADD CONSTRAINT character_spells_spell_level_check CHECK (spell_level >= 1);
+
+-- La clé primaire (character_id, spell_id) couvre déjà cet index
+DROP INDEX IF EXISTS idx_character_spell;
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE character_spells IS 'Sorts appris par les personnages';
+COMMENT ON COLUMN character_spells.level_learned IS 'Niveau du personnage lors de l''apprentissage';
+COMMENT ON COLUMN character_spells.spell_level IS 'Niveau du sort, augmenté par LevelUpSpell';
@@ -0,0 +1,11 @@
+-- Migration pour revenir à la liste des classes codée en dur dans characters
+-- Les personnages d'une autre classe que warrior ou archer empêchent le retour en arrière.
+DROP INDEX IF EXISTS idx_characters_class;
+ALTER TABLE characters DROP CONSTRAINT IF EXISTS characters_class_fkey;
+ALTER TABLE characters
+    ADD CONSTRAINT characters_class_check
+    CHECK (class IN ('warrior', 'archer'));
+
+DROP TABLE IF EXISTS character_classes;
+
+COMMENT ON COLUMN characters.class IS 'Classe du personnage: warrior, archer';
@@ -0,0 +1,57 @@
+-- Migration pour créer la table character_classes
+-- Les classes jouables sont des données de référence (seeds/002_classes.sql) chargées au démarrage
+-- dans le registre des classes : la liste n'est plus codée en dur dans la contrainte de characters.class.
+CREATE TABLE IF NOT EXISTS character_classes (
+    id VARCHAR(20) PRIMARY KEY CHECK (id ~ '^[a-z][a-z_]*$'),
+    name VARCHAR(64) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    icon_path VARCHAR(255) NOT NULL DEFAULT '',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+
+    -- Stats au niveau 1
+    base_vitality INTEGER NOT NULL DEFAULT 10 CHECK (base_vitality >= 0),
+    base_wisdom INTEGER NOT NULL DEFAULT 10 CHECK (base_wisdom >= 0),
+    base_strength INTEGER NOT NULL DEFAULT 10 CHECK (base_strength >= 0),
+    base_intelligence INTEGER NOT NULL DEFAULT 10 CHECK (base_intelligence >= 0),
+    base_chance INTEGER NOT NULL DEFAULT 10 CHECK (base_chance >= 0),
+    base_agility INTEGER NOT NULL DEFAULT 10 CHECK (base_agility >= 0),
+
+    -- Stats gagnées à chaque niveau
+    growth_vitality INTEGER NOT NULL DEFAULT 0 CHECK (growth_vitality >= 0),
+    growth_wisdom INTEGER NOT NULL DEFAULT 0 CHECK (growth_wisdom >= 0),
+    growth_strength INTEGER NOT NULL DEFAULT 0 CHECK (growth_strength >= 0),
+    growth_intelligence INTEGER NOT NULL DEFAULT 0 CHECK (growth_intelligence >= 0),
+    growth_chance INTEGER NOT NULL DEFAULT 0 CHECK (growth_chance >= 0),
+    growth_agility INTEGER NOT NULL DEFAULT 0 CHECK (growth_agility >= 0),
+
+    -- Sorts appris à la création (identifiants de spell_templates)
+    starting_spells VARCHAR(64)[] NOT NULL DEFAULT '{}',
+
+    -- Position de départ
+    start_map_x INTEGER NOT NULL DEFAULT 0,
+    start_map_y INTEGER NOT NULL DEFAULT 0,
+    start_pos_x INTEGER NOT NULL DEFAULT 15 CHECK (start_pos_x >= 0 AND start_pos_x < 30),
+    start_pos_y INTEGER NOT NULL DEFAULT 15 CHECK (start_pos_y >= 0 AND start_pos_y < 30),
+
+    game_version VARCHAR(16)
+);
+
+-- Classes déjà utilisées par des personnages, complétées par les données de référence
+INSERT INTO character_classes (id, name)
+SELECT DISTINCT class, class FROM characters
+ON CONFLICT (id) DO NOTHING;
+
+-- La classe d'un personnage doit exister dans le registre
+ALTER TABLE characters DROP CONSTRAINT IF EXISTS characters_class_check;
+ALTER TABLE characters
+    ADD CONSTRAINT characters_class_fkey
+    FOREIGN KEY (class) REFERENCES character_classes(id);
+
+-- Index pour optimiser les requêtes
+CREATE INDEX IF NOT EXISTS idx_characters_class ON characters(class);
+
+-- Commentaires pour la documentation
+COMMENT ON TABLE character_classes IS 'Classes jouables, chargées au démarrage dans le registre des classes';
+COMMENT ON COLUMN character_classes.sort_order IS 'Ordre d''affichage dans la liste des classes';
+COMMENT ON COLUMN character_classes.starting_spells IS 'Sorts appris à la création du personnage';
+COMMENT ON COLUMN characters.class IS 'Classe du personnage (character_classes.id)';
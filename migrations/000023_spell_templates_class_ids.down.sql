@@ -0,0 +1,6 @@
+-- Migration pour revenir aux noms de classe capitalisés dans spell_templates
+ALTER TABLE spell_templates DROP CONSTRAINT IF EXISTS spell_templates_class_check;
+
+UPDATE spell_templates SET class = INITCAP(class) WHERE class IS NOT NULL;
+
+COMMENT ON COLUMN spell_templates.class IS NULL;
@@ -0,0 +1,13 @@
+-- Migration pour aligner spell_templates.class sur les identifiants de character_classes
+-- Les données de référence utilisaient le nom capitalisé ("Warrior") alors que characters.class
+-- contient l'identifiant ("warrior") : les comparaisons se font désormais à l'identique.
+UPDATE spell_templates SET class = LOWER(class) WHERE class <> LOWER(class);
+
+-- Sorts communs : NULL plutôt qu'une chaîne vide
+UPDATE spell_templates SET class = NULL WHERE class = '';
+
+ALTER TABLE spell_templates
+    ADD CONSTRAINT spell_templates_class_check
+    CHECK (class ~ '^[a-z][a-z_]*$');
+
+COMMENT ON COLUMN spell_templates.class IS 'Classe du sort (character_classes.id), NULL pour un sort commun';
@@ -0,0 +1,10 @@
+// Package migrations embarque les migrations SQL et les données de référence dans le binaire du serveur.
+//
+// Les migrations suivent le format NNNNNN_nom.up.sql / NNNNNN_nom.down.sql ; les données de
+// référence (rejouables) sont dans seeds/. Elles sont appliquées par database.Migrator.
+package migrations
+
+import "embed"
+
+//go:embed *.sql seeds/*.sql
+var FS embed.FS
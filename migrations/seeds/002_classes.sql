@@ -0,0 +1,43 @@
+-- Données de référence : classes jouables
+-- Rejouable : les classes existantes sont mises à jour. Les sorts de départ doivent exister dans
+-- spell_templates (001_spells.sql) et être accessibles à la classe au niveau 1 : le serveur le vérifie
+-- au démarrage. Les gains par niveau sont ajoutés aux stats à chaque montée de niveau.
+INSERT INTO character_classes
+(id, name, description, icon_path, sort_order,
+ base_vitality, base_wisdom, base_strength, base_intelligence, base_chance, base_agility,
+ growth_vitality, growth_wisdom, growth_strength, growth_intelligence, growth_chance, growth_agility,
+ starting_spells, start_map_x, start_map_y, start_pos_x, start_pos_y, game_version)
+VALUES
+-- Guerrier : forte Vitalité et Force
+('warrior', 'Guerrier', 'Combattant au corps à corps, maître des armes lourdes', 'res://assets/classes/warrior_icon.png', 1,
+ 20, 10, 15, 5, 5, 10,
+ 2, 1, 1, 0, 0, 1,
+ '{WAR_SLASH}', 0, 0, 15, 15, '1'),
+-- Archer : forte Chance (dommages distance) et Agilité
+('archer', 'Archer', 'Combattant à distance, précis et agile', 'res://assets/classes/archer_icon.png', 2,
+ 15, 10, 5, 5, 15, 15,
+ 1, 1, 0, 0, 1, 1,
+ '{ARC_POWER_SHOT}', 0, 0, 15, 15, '1')
+ON CONFLICT (id) DO UPDATE SET
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    icon_path = EXCLUDED.icon_path,
+    sort_order = EXCLUDED.sort_order,
+    base_vitality = EXCLUDED.base_vitality,
+    base_wisdom = EXCLUDED.base_wisdom,
+    base_strength = EXCLUDED.base_strength,
+    base_intelligence = EXCLUDED.base_intelligence,
+    base_chance = EXCLUDED.base_chance,
+    base_agility = EXCLUDED.base_agility,
+    growth_vitality = EXCLUDED.growth_vitality,
+    growth_wisdom = EXCLUDED.growth_wisdom,
+    growth_strength = EXCLUDED.growth_strength,
+    growth_intelligence = EXCLUDED.growth_intelligence,
+    growth_chance = EXCLUDED.growth_chance,
+    growth_agility = EXCLUDED.growth_agility,
+    starting_spells = EXCLUDED.starting_spells,
+    start_map_x = EXCLUDED.start_map_x,
+    start_map_y = EXCLUDED.start_map_y,
+    start_pos_x = EXCLUDED.start_pos_x,
+    start_pos_y = EXCLUDED.start_pos_y,
+    game_version = EXCLUDED.game_version;